@@ -3,71 +3,126 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 
-	"github.com/joho/godotenv"
+	"github.com/XSAM/otelsql"
+	"github.com/josenymad/boulder-api/mail"
 	"github.com/josenymad/boulder-api/types"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var DB *sql.DB
 
-const DefaultPort string = ":8080"
-
-func ConnectDB(version string) error {
-	envVars := GetEnvVars(version)
-
+// ConnectDB opens the database described by database and checks that it can
+// be reached.
+func ConnectDB(database DatabaseConfig) error {
 	var err error
-
-	portInt, err := strconv.Atoi(envVars.Port)
-	if err != nil {
-		return fmt.Errorf("failed to convert port to integer: %v", err)
-	}
-
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		envVars.Host, portInt, envVars.User, envVars.Password, envVars.Name)
-
-	DB, err = sql.Open("postgres", connStr)
+	DB, err = otelsql.Open("postgres", database.ConnectionString(), otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
 		return fmt.Errorf("failed to open database: %v", err)
 	}
+	DB.SetMaxOpenConns(database.MaxOpenConns)
+	DB.SetMaxIdleConns(database.MaxIdleConns)
+	DB.SetConnMaxLifetime(database.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(database.ConnMaxIdleTime)
 
 	err = DB.Ping()
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
-	fmt.Println("Connected to the database")
+	slog.Info("Connected to the database")
 	return nil
 }
 
-func GetEnvVars(version string) types.EnvVars {
-	switch version {
-	case "test":
-		err := godotenv.Load("../.env.test")
-		if err != nil {
-			log.Fatalf("Error loading .env file: %v", err)
-		}
-	case "development":
-		err := godotenv.Load(".env.test")
-		if err != nil {
-			log.Fatalf("Error loading .env file: %v", err)
+// ConnectionString returns DSN if it is set, or a key/value connection string
+// built from the other fields. Optional fields are left out when empty.
+func (database DatabaseConfig) ConnectionString() string {
+	if database.DSN != "" {
+		return database.DSN
+	}
+
+	params := []string{
+		"host=" + quoteConnValue(database.Host),
+		"port=" + strconv.Itoa(database.Port),
+		"user=" + quoteConnValue(database.User),
+		"password=" + quoteConnValue(database.Password),
+		"dbname=" + quoteConnValue(database.Name),
+		"sslmode=" + quoteConnValue(database.SSLMode),
+	}
+	optional := []struct{ key, value string }{
+		{"sslrootcert", database.SSLRootCert},
+		{"sslcert", database.SSLCert},
+		{"sslkey", database.SSLKey},
+	}
+	for _, param := range optional {
+		if param.value != "" {
+			params = append(params, param.key+"="+quoteConnValue(param.value))
 		}
-	default:
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatalf("Error loading .env file: %v", err)
+	}
+	return strings.Join(params, " ")
+}
+
+// quoteConnValue quotes a connection string value when it is empty or holds
+// spaces, quotes or backslashes, as libpq expects.
+func quoteConnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// NewMailer returns an SMTP mailer configured from the environment, or nil if
+// SMTP_HOST isn't set.
+func NewMailer() mail.Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return mail.SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}
+}
+
+// LoadRateLimit reads the RATE_LIMIT_<NAME>_PER_MINUTE and
+// RATE_LIMIT_<NAME>_BURST environment variables, using fallback for any that
+// are unset or invalid.
+func LoadRateLimit(name string, fallback types.RateLimit) types.RateLimit {
+	limit := fallback
+	prefix := "RATE_LIMIT_" + name
+
+	if value := os.Getenv(prefix + "_PER_MINUTE"); value != "" {
+		perMinute, err := strconv.ParseFloat(value, 64)
+		if err != nil || perMinute <= 0 {
+			slog.Warn("Ignoring invalid rate limit", "variable", prefix+"_PER_MINUTE", "value", value)
+		} else {
+			limit.PerMinute = perMinute
 		}
 	}
 
-	envVars := types.EnvVars{
-		Host:     os.Getenv("HOST"),
-		Port:     os.Getenv("PORT"),
-		User:     os.Getenv("POSTGRES_USER"),
-		Password: os.Getenv("POSTGRES_PASSWORD"),
-		Name:     os.Getenv("DB_NAME"),
+	if value := os.Getenv(prefix + "_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst <= 0 {
+			slog.Warn("Ignoring invalid rate limit", "variable", prefix+"_BURST", "value", value)
+		} else {
+			limit.Burst = burst
+		}
 	}
 
-	return envVars
+	return limit
 }
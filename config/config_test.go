@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josenymad/boulder-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeEnvFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	return path
+}
+
+// unsetAfter removes variables an env file adds to the process environment.
+func unsetAfter(t *testing.T, keys ...string) {
+	t.Cleanup(func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeEnvFile(t, "DB_NAME=file-db\nLISTEN_ADDR=:7000\nLOG_LEVEL=warn\nADMIN_TOKEN=secret\n")
+	unsetAfter(t, "DB_NAME", "LISTEN_ADDR", "LOG_LEVEL", "ADMIN_TOKEN")
+	t.Setenv("LISTEN_ADDR", ":9000")
+	t.Setenv("READ_TIMEOUT", "30s")
+
+	cfg, err := config.Load([]string{"-config", path, "-log-level", "debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, "file-db", cfg.Database.Name, "file values apply")
+	assert.Equal(t, ":9000", cfg.ListenAddr, "environment overrides the file")
+	assert.Equal(t, "debug", cfg.LogLevel, "flags override the environment")
+	assert.Equal(t, 30*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, config.Default().WriteTimeout, cfg.WriteTimeout)
+	assert.False(t, cfg.DocsUI, "the docs page is off unless turned on")
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := config.Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")})
+	assert.Error(t, err)
+	_, err = config.Load([]string{"-dev"})
+	assert.Error(t, err, "env files are named with -config, not by shortcut flags")
+
+	t.Setenv("DB_NAME", "boulder")
+	t.Setenv("READ_TIMEOUT", "soon")
+	_, err = config.Load([]string{"-config", writeEnvFile(t, "")})
+	assert.ErrorContains(t, err, "READ_TIMEOUT")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Name = "boulder"
+	assert.ErrorContains(t, cfg.Validate(), "admin token")
+	cfg.Development = true
+	assert.NoError(t, cfg.Validate(), "development can run without admin routes")
+	cfg.Development = false
+	cfg.AdminToken = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Database.SSLMode = "sometimes"
+	cfg.LogLevel = "loud"
+	cfg.Database.Port = 0
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "sslmode")
+	assert.ErrorContains(t, err, "log level")
+	assert.ErrorContains(t, err, "port")
+
+	cfg = config.Default()
+	cfg.Database.Name = "boulder"
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 5
+	assert.ErrorContains(t, cfg.Validate(), "idle")
+
+	cfg = config.Default()
+	cfg.AdminToken = "secret"
+	cfg.Database.DSN = "postgres://localhost/boulder"
+	assert.NoError(t, cfg.Validate(), "a DSN replaces the other database settings")
+
+	cfg = config.Default()
+	cfg.Database.Name = "boulder"
+	cfg.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1", "proxy.internal"}
+	assert.ErrorContains(t, cfg.Validate(), `"proxy.internal"`)
+}
+
+func TestConnectionString(t *testing.T) {
+	database := config.DatabaseConfig{
+		Host:     "db.example.com",
+		Port:     5432,
+		User:     "boulder",
+		Password: `it's a s\ecret`,
+		Name:     "boulder-db",
+		SSLMode:  "verify-full",
+	}
+	assert.Equal(t, `host=db.example.com port=5432 user=boulder password='it\'s a s\\ecret' dbname=boulder-db sslmode=verify-full`,
+		database.ConnectionString())
+
+	database.Password = ""
+	database.SSLRootCert = "/etc/ssl/ca.pem"
+	database.SSLCert = "/etc/ssl/client.pem"
+	database.SSLKey = "/etc/ssl/client key.pem"
+	assert.Equal(t, `host=db.example.com port=5432 user=boulder password='' dbname=boulder-db sslmode=verify-full `+
+		`sslrootcert=/etc/ssl/ca.pem sslcert=/etc/ssl/client.pem sslkey='/etc/ssl/client key.pem'`,
+		database.ConnectionString())
+
+	database.DSN = "postgres://boulder@db.example.com/boulder-db?sslmode=require"
+	assert.Equal(t, database.DSN, database.ConnectionString(), "a DSN is used as is")
+}
+
+func TestValidateCertificates(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Name = "boulder"
+	cfg.Database.SSLCert = writeEnvFile(t, "")
+	assert.ErrorContains(t, cfg.Validate(), "set together")
+
+	cfg.Database.SSLKey = filepath.Join(t.TempDir(), "missing.key")
+	assert.ErrorContains(t, cfg.Validate(), "missing.key")
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/josenymad/boulder-api/tracing"
+)
+
+// Config holds the server's settings. Load fills it from, in increasing order
+// of precedence: defaults, an optional env file, environment variables and
+// command line flags.
+type Config struct {
+	ListenAddr      string
+	Database        DatabaseConfig
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	ShutdownDelay   time.Duration
+	QueryTimeout    time.Duration
+	LogLevel        string
+	TraceExporter   string
+	TraceSampling   float64
+	DocsUI          bool
+	TrustedProxies  []string
+	MailDev         bool
+	AdminToken      string
+	Development     bool
+}
+
+// DatabaseConfig describes how to reach Postgres. DSN, when set, is used as
+// the whole connection string and the other fields are ignored.
+type DatabaseConfig struct {
+	DSN             string
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	Name            string
+	SSLMode         string
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// Default returns the settings used when nothing else is configured.
+func Default() Config {
+	return Config{
+		ListenAddr: ":8080",
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    15 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+		ShutdownDelay:   5 * time.Second,
+		QueryTimeout:    10 * time.Second,
+		LogLevel:        "info",
+		TraceExporter:   tracing.ExporterNone,
+		TraceSampling:   1,
+		DocsUI:          false,
+	}
+}
+
+// setting is a Config field that can be set by an environment variable and a
+// command line flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(config *Config, value string) error
+}
+
+var settings = []setting{
+	{"LISTEN_ADDR", "listen", "address to listen on", func(config *Config, value string) error {
+		config.ListenAddr = value
+		return nil
+	}},
+	{"DATABASE_URL", "dsn", "Postgres connection string, overriding the other database settings", func(config *Config, value string) error {
+		config.Database.DSN = value
+		return nil
+	}},
+	{"HOST", "db-host", "database host", func(config *Config, value string) error {
+		config.Database.Host = value
+		return nil
+	}},
+	{"PORT", "db-port", "database port", func(config *Config, value string) error {
+		return parseInt(value, &config.Database.Port)
+	}},
+	{"POSTGRES_USER", "db-user", "database user", func(config *Config, value string) error {
+		config.Database.User = value
+		return nil
+	}},
+	{"POSTGRES_PASSWORD", "db-password", "database password", func(config *Config, value string) error {
+		config.Database.Password = value
+		return nil
+	}},
+	{"DB_NAME", "db-name", "database name", func(config *Config, value string) error {
+		config.Database.Name = value
+		return nil
+	}},
+	{"DB_SSLMODE", "db-sslmode", "database sslmode", func(config *Config, value string) error {
+		config.Database.SSLMode = value
+		return nil
+	}},
+	{"DB_SSLROOTCERT", "db-sslrootcert", "CA certificate file used to verify the database server", func(config *Config, value string) error {
+		config.Database.SSLRootCert = value
+		return nil
+	}},
+	{"DB_SSLCERT", "db-sslcert", "client certificate file for the database", func(config *Config, value string) error {
+		config.Database.SSLCert = value
+		return nil
+	}},
+	{"DB_SSLKEY", "db-sslkey", "client key file for the database", func(config *Config, value string) error {
+		config.Database.SSLKey = value
+		return nil
+	}},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections, 0 for no limit", func(config *Config, value string) error {
+		return parseInt(value, &config.Database.MaxOpenConns)
+	}},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", func(config *Config, value string) error {
+		return parseInt(value, &config.Database.MaxIdleConns)
+	}},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum time a database connection is reused, 0 for no limit", func(config *Config, value string) error {
+		return parseDuration(value, &config.Database.ConnMaxLifetime)
+	}},
+	{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum time a database connection stays idle, 0 for no limit", func(config *Config, value string) error {
+		return parseDuration(value, &config.Database.ConnMaxIdleTime)
+	}},
+	{"READ_TIMEOUT", "read-timeout", "maximum time to read a request", func(config *Config, value string) error {
+		return parseDuration(value, &config.ReadTimeout)
+	}},
+	{"WRITE_TIMEOUT", "write-timeout", "maximum time to write a response", func(config *Config, value string) error {
+		return parseDuration(value, &config.WriteTimeout)
+	}},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time to let requests finish when shutting down", func(config *Config, value string) error {
+		return parseDuration(value, &config.ShutdownTimeout)
+	}},
+	{"SHUTDOWN_DELAY", "shutdown-delay", "time to report not ready before shutting down", func(config *Config, value string) error {
+		return parseDuration(value, &config.ShutdownDelay)
+	}},
+	{"QUERY_TIMEOUT", "query-timeout", "maximum time a request's database queries can take, 0 for no limit", func(config *Config, value string) error {
+		return parseDuration(value, &config.QueryTimeout)
+	}},
+	{"LOG_LEVEL", "log-level", "one of debug, info, warn or error", func(config *Config, value string) error {
+		config.LogLevel = value
+		return nil
+	}},
+	{"TRACE_EXPORTER", "trace-exporter", "where to send traces: none, otlp or stdout", func(config *Config, value string) error {
+		config.TraceExporter = value
+		return nil
+	}},
+	{"TRACE_SAMPLING", "trace-sampling", "fraction of requests to trace, from 0 to 1", func(config *Config, value string) error {
+		sampling, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		config.TraceSampling = sampling
+		return nil
+	}},
+	{"DOCS_UI", "docs-ui", "serve interactive API documentation at /docs", func(config *Config, value string) error {
+		docsUI, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		config.DocsUI = docsUI
+		return nil
+	}},
+	{"MAIL_DEV", "mail-dev", "keep emails in memory instead of sending them when SMTP_HOST isn't set", func(config *Config, value string) error {
+		mailDev, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		config.MailDev = mailDev
+		return nil
+	}},
+	{"ADMIN_TOKEN", "admin-token", "bearer token for the /admin routes", func(config *Config, value string) error {
+		config.AdminToken = value
+		return nil
+	}},
+	{"DEVELOPMENT", "development", "allow settings only fit for development: no ADMIN_TOKEN disables the admin routes", func(config *Config, value string) error {
+		development, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		config.Development = development
+		return nil
+	}},
+	{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated IPs or CIDR ranges of proxies whose X-Forwarded-For header is believed", func(config *Config, value string) error {
+		config.TrustedProxies = nil
+		for _, proxy := range strings.Split(value, ",") {
+			config.TrustedProxies = append(config.TrustedProxies, strings.TrimSpace(proxy))
+		}
+		return nil
+	}},
+}
+
+// Load reads the configuration from args, the process environment and an env
+// file. The file is named by the -config flag or CONFIG_FILE, and defaults to
+// .env when that exists. Its values are added to the environment without
+// replacing variables that are already set, so other settings read from the
+// environment can also live in it.
+func Load(args []string) (Config, error) {
+	config := Default()
+
+	flags := flag.NewFlagSet("boulder-api", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "env file to load settings from")
+	values := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		values[s.flag] = flags.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := loadEnvFile(*configFile); err != nil {
+		return config, err
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(&config, value); err != nil {
+				return config, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(&config, *values[s.flag]); setErr != nil {
+					err = fmt.Errorf("invalid -%s: %w", s.flag, setErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return config, err
+	}
+
+	return config, config.Validate()
+}
+
+func loadEnvFile(path string) error {
+	if path == "" {
+		err := godotenv.Load()
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := godotenv.Load(path); err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	return nil
+}
+
+// SlogLevel returns LogLevel as a slog level. Validate checks that LogLevel is
+// one slog understands.
+func (config Config) SlogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(config.LogLevel))
+	return level
+}
+
+// Validate reports every setting that is missing or out of range.
+func (config Config) Validate() error {
+	var errs []error
+	if config.ListenAddr == "" {
+		errs = append(errs, errors.New("listen address is required"))
+	}
+	if config.Database.DSN == "" {
+		if config.Database.Host == "" {
+			errs = append(errs, errors.New("database host is required"))
+		}
+		if config.Database.Port <= 0 || config.Database.Port > 65535 {
+			errs = append(errs, fmt.Errorf("database port %d is out of range", config.Database.Port))
+		}
+		if config.Database.Name == "" {
+			errs = append(errs, errors.New("database name is required"))
+		}
+		if !slices.Contains(sslModes, config.Database.SSLMode) {
+			errs = append(errs, fmt.Errorf("unknown sslmode %q", config.Database.SSLMode))
+		}
+		if (config.Database.SSLCert == "") != (config.Database.SSLKey == "") {
+			errs = append(errs, errors.New("database client certificate and key must be set together"))
+		}
+		for _, path := range []string{config.Database.SSLRootCert, config.Database.SSLCert, config.Database.SSLKey} {
+			if _, err := os.Stat(path); path != "" && err != nil {
+				errs = append(errs, fmt.Errorf("database certificate: %w", err))
+			}
+		}
+	}
+	if config.Database.MaxOpenConns < 0 || config.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database connection limits can't be negative"))
+	}
+	if config.Database.MaxOpenConns > 0 && config.Database.MaxIdleConns > config.Database.MaxOpenConns {
+		errs = append(errs, errors.New("max idle connections can't be more than max open connections"))
+	}
+	if config.Database.ConnMaxLifetime < 0 || config.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database connection lifetimes can't be negative"))
+	}
+	if config.ReadTimeout < 0 || config.WriteTimeout < 0 || config.ShutdownTimeout < 0 || config.ShutdownDelay < 0 || config.QueryTimeout < 0 {
+		errs = append(errs, errors.New("timeouts can't be negative"))
+	}
+	if !slices.Contains(logLevels, config.LogLevel) {
+		errs = append(errs, fmt.Errorf("unknown log level %q", config.LogLevel))
+	}
+	if !slices.Contains(tracing.Exporters, config.TraceExporter) {
+		errs = append(errs, fmt.Errorf("unknown trace exporter %q", config.TraceExporter))
+	}
+	if config.TraceSampling < 0 || config.TraceSampling > 1 {
+		errs = append(errs, errors.New("trace sampling must be between 0 and 1"))
+	}
+	if config.AdminToken == "" && !config.Development {
+		errs = append(errs, errors.New("admin token is required outside development"))
+	}
+	for _, proxy := range config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("trusted proxy %q is not an IP or CIDR range", proxy))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseInt(value string, dest *int) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*dest = parsed
+	return nil
+}
+
+func parseDuration(value string, dest *time.Duration) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*dest = parsed
+	return nil
+}
@@ -0,0 +1,26 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/josenymad/boulder-api/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.New(&buffer, slog.LevelInfo).With("component", "test")
+
+	logger.Debug("Hidden")
+	logger.InfoContext(logging.WithRequestID(context.Background(), "abc123"), "Shown")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert.Equal(t, "Shown", line["msg"])
+	assert.Equal(t, "abc123", line["request_id"])
+	assert.Equal(t, "test", line["component"])
+}
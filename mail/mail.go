@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// SMTPMailer sends mail through an SMTP server, authenticating with PLAIN auth
+// when a username is set.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	err := smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{message.To}, FormatMessage(m.From, message))
+	if err != nil {
+		return fmt.Errorf("failed to send mail to %s: %v", message.To, err)
+	}
+	return nil
+}
+
+// headerReplacer strips line breaks from header values so they can't add
+// headers of their own.
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// FormatMessage renders a message as a plain text email.
+func FormatMessage(from string, message Message) []byte {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "From: %s\r\n", headerReplacer.Replace(from))
+	fmt.Fprintf(&builder, "To: %s\r\n", headerReplacer.Replace(message.To))
+	fmt.Fprintf(&builder, "Subject: %s\r\n", headerReplacer.Replace(message.Subject))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(builder.String())
+}
+
+// MemoryMailer keeps sent messages in memory instead of sending them, for
+// tests and for running without an SMTP server.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *MemoryMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,33 @@
+package mail_test
+
+import (
+	"testing"
+
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := &mail.MemoryMailer{}
+
+	err := mailer.Send(mail.Message{To: "test@mail.com", Subject: "Hello", Body: "Test body"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []mail.Message{{To: "test@mail.com", Subject: "Hello", Body: "Test body"}}, mailer.Messages())
+}
+
+func TestFormatMessage(t *testing.T) {
+	message := mail.FormatMessage("league@mail.com", mail.Message{
+		To:      "test@mail.com",
+		Subject: "Reset your password",
+		Body:    "Line one\nLine two",
+	})
+
+	assert.Equal(t, "From: league@mail.com\r\n"+
+		"To: test@mail.com\r\n"+
+		"Subject: Reset your password\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"Line one\r\nLine two", string(message))
+}
@@ -2,87 +2,129 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/logging"
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/josenymad/boulder-api/metrics"
 	"github.com/josenymad/boulder-api/routes"
+	"github.com/josenymad/boulder-api/tracing"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
 	_ "github.com/lib/pq"
 )
 
-var testFlag = flag.Bool("test", false, "Run in test mode")
-var developmentFlag = flag.Bool("dev", false, "Run in development mode")
-
 func main() {
-	flag.Parse()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
 
-	if *testFlag {
-		fmt.Println("Running in test mode")
-		err := config.ConnectDB("test")
-		if err != nil {
-			log.Fatalf("Could not set up database: %v", err)
-		}
-	} else if *developmentFlag {
-		fmt.Println("Running in development mode")
-		err := config.ConnectDB("development")
-		if err != nil {
-			log.Fatalf("Could not set up database: %v", err)
-		}
-	} else {
-		fmt.Println("Running in normal mode")
-		err := config.ConnectDB("")
-		if err != nil {
-			log.Fatalf("Could not set up database: %v", err)
-		}
+	slog.SetDefault(logging.New(os.Stdout, cfg.SlogLevel()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter, cfg.TraceSampling)
+	if err != nil {
+		fatal("Could not set up tracing", err)
+	}
+
+	if cfg.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	if err := config.ConnectDB(cfg.Database); err != nil {
+		fatal("Could not set up database", err)
 	}
 
 	defer config.DB.Close()
 
-	router := gin.Default()
-
-	router.GET("/health", routes.HealthCheckHandler)
-	router.POST("/competition", routes.CreateCompetition)
-	router.POST("/categories", routes.CreateCompetitionCategory)
-	router.POST("/rounds", routes.CreateRound)
-	router.POST("/competitors", routes.CreateCompetitor)
-	router.POST("/boulder-problems", routes.CreateBoulderProblem)
-	router.POST("/scores", routes.CreateScore)
-	router.GET("/competitions", routes.GetAllCompetitions)
-	router.GET("/categories", routes.GetAllCategories)
-	router.GET("/boulder-problems/:round", routes.GetBoulderProblems)
-	router.GET("/rounds/:competition", routes.GetAllRounds)
-	router.GET("/competitors", routes.GetAllCompetitors)
-	router.GET("/scores", routes.GetAllScores)
+	if err := metrics.RegisterDB(config.DB, cfg.Database.Name); err != nil {
+		fatal("Could not register database metrics", err)
+	}
+
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		fatal("Could not set up email", err)
+	}
+
+	router := routes.NewRouter(newDeps(cfg, mailer))
 
 	// Graceful shutdown
 	srv := &http.Server{
-		Addr:    config.DefaultPort,
-		Handler: router,
+		Addr:         cfg.ListenAddr,
+		Handler:      router,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	}
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("ListenAndServe(): %s\n", err)
+			fatal("Server stopped unexpectedly", err)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with
-	// a timeout of 5 seconds.
+	// Wait for interrupt signal to gracefully shutdown the server, giving
+	// requests in flight up to the shutdown timeout to finish.
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Fail readiness first and give load balancers time to notice before
+	// connections are drained.
+	routes.MarkShuttingDown()
+	time.Sleep(cfg.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("Server forced to shutdown", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server exiting")
+}
+
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+// newMailer returns the SMTP mailer, or with MAIL_DEV set and no SMTP_HOST a
+// mailer that keeps emails in memory. Without either, competitors would never
+// get their verification and reset codes, so it is an error.
+func newMailer(cfg config.Config) (mail.Mailer, error) {
+	if mailer := config.NewMailer(); mailer != nil {
+		return mailer, nil
+	}
+	if !cfg.MailDev {
+		return nil, errors.New("SMTP_HOST is not set; set MAIL_DEV=true to keep emails in memory instead")
+	}
+	slog.Warn("MAIL_DEV is set and SMTP_HOST is not, emails will be kept in memory instead of sent")
+	return &mail.MemoryMailer{}, nil
+}
+
+// newDeps builds what the router needs from the configuration.
+func newDeps(cfg config.Config, mailer mail.Mailer) routes.Deps {
+	return routes.Deps{
+		Mailer:         mailer,
+		AuthLimiter:    utils.NewRateLimiter(config.LoadRateLimit("AUTH", types.RateLimit{PerMinute: 10, Burst: 5})),
+		ScoresLimiter:  utils.NewRateLimiter(config.LoadRateLimit("SCORES", types.RateLimit{PerMinute: 120, Burst: 30})),
+		QueryTimeout:   cfg.QueryTimeout,
+		DocsUI:         cfg.DocsUI,
+		TrustedProxies: cfg.TrustedProxies,
+		AdminToken:     cfg.AdminToken,
 	}
-	log.Println("Server exiting")
 }
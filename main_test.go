@@ -0,0 +1,261 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/josenymad/boulder-api/openapi"
+	"github.com/josenymad/boulder-api/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, operation := range openapi.Operations {
+		documented[operation.Method+" "+operation.Path] = true
+	}
+
+	cfg := config.Default()
+	cfg.DocsUI = true
+	registered := make(map[string]bool)
+	for _, route := range routes.NewRouter(newDeps(cfg, &mail.MemoryMailer{})).Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if !documented[key] {
+			// Deprecated aliases are documented by the route they alias.
+			key = route.Method + " " + routes.APIVersion + route.Path
+		}
+		assert.True(t, documented[key], "%s %s has no entry in openapi.Operations", route.Method, route.Path)
+	}
+	for key := range documented {
+		assert.True(t, registered[key], "openapi.Operations documents %s, which isn't registered", key)
+	}
+}
+
+// TestDocumentedSchemasMatchHandlers checks each operation's request and
+// response types, and its success status, against what the route's handler
+// binds and writes with c.JSON.
+func TestDocumentedSchemasMatchHandlers(t *testing.T) {
+	handlers := handlerBodies(t)
+
+	documented := make(map[string]openapi.Operation)
+	for _, operation := range openapi.Operations {
+		documented[operation.Method+" "+operation.Path] = operation
+	}
+
+	cfg := config.Default()
+	cfg.DocsUI = true
+	for _, route := range routes.NewRouter(newDeps(cfg, &mail.MemoryMailer{})).Routes() {
+		// Deprecated aliases share their handler with the route they alias, and
+		// missing entries are TestEveryRouteIsDocumented's to report.
+		operation, ok := documented[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+
+		name := route.Handler[strings.LastIndex(route.Handler, ".")+1:]
+		handler, ok := handlers[name]
+		if !ok {
+			assert.NotEmpty(t, operation.Produces, "%s %s: handler %s isn't a routes function writing JSON, so the operation should set Produces", route.Method, route.Path, route.Handler)
+			continue
+		}
+
+		request := ""
+		if operation.Request != nil {
+			request = typeName(operation.Request)
+		}
+		assert.Equal(t, request, strings.Join(handler.requests, ", "), "%s %s request", route.Method, route.Path)
+
+		response := "gin.H"
+		if operation.Response != nil {
+			response = typeName(operation.Response)
+		}
+		status := operation.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		for _, written := range handler.responses {
+			assert.Equal(t, response, written.body, "%s %s response", route.Method, route.Path)
+			if written.status != 0 {
+				assert.Equal(t, status, written.status, "%s %s status", route.Method, route.Path)
+			}
+		}
+	}
+}
+
+// handlerBody is what a routes function binds and writes on success.
+type handlerBody struct {
+	requests  []string
+	responses []struct {
+		status int // 0 when it isn't a constant
+		body   string
+	}
+}
+
+// handlerBodies type-checks the routes package and records, for each function,
+// the types it passes to BindJSON and to c.JSON with a 2xx or non-constant
+// status. Imports come from the build cache via go list, which is much faster
+// than type-checking them from source.
+func handlerBodies(t *testing.T) map[string]handlerBody {
+	t.Helper()
+
+	out, err := exec.Command("go", "list", "-export", "-deps", "-f", "{{.ImportPath}}={{.Export}}", "./routes").Output()
+	if err != nil {
+		t.Fatalf("Failed to list export data: %v", err)
+	}
+	exports := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		path, file, _ := strings.Cut(line, "=")
+		exports[path] = file
+	}
+
+	fset := token.NewFileSet()
+	names, err := filepath.Glob("routes/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []*ast.File
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	checker := types.Config{Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		return os.Open(exports[path])
+	})}
+	if _, err := checker.Check("github.com/josenymad/boulder-api/routes", fset, files, info); err != nil {
+		t.Fatalf("Failed to type-check routes: %v", err)
+	}
+
+	typeString := func(expr ast.Expr) string {
+		typ := info.Types[expr].Type
+		if pointer, ok := typ.(*types.Pointer); ok {
+			typ = pointer.Elem()
+		}
+		return strings.ReplaceAll(types.TypeString(typ, (*types.Package).Name), " ", "")
+	}
+
+	handlers := make(map[string]handlerBody)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			function, ok := decl.(*ast.FuncDecl)
+			if !ok || function.Body == nil {
+				continue
+			}
+			var handler handlerBody
+			ast.Inspect(function.Body, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				selector, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				switch {
+				case selector.Sel.Name == "BindJSON" && len(call.Args) == 1:
+					handler.requests = append(handler.requests, typeString(call.Args[0]))
+				case selector.Sel.Name == "JSON" && len(call.Args) == 2:
+					status := 0
+					if value := info.Types[call.Args[0]].Value; value != nil {
+						status, _ = strconv.Atoi(value.ExactString())
+						if status < 200 || status > 299 {
+							return true
+						}
+					}
+					handler.responses = append(handler.responses, struct {
+						status int
+						body   string
+					}{status, typeString(call.Args[1])})
+				}
+				return true
+			})
+			if handler.requests != nil || handler.responses != nil {
+				handlers[function.Name.Name] = handler
+			}
+		}
+	}
+	return handlers
+}
+
+// typeName spells value's type the way go/types does in handlerBodies.
+func typeName(value interface{}) string {
+	return strings.ReplaceAll(reflect.TypeOf(value).String(), " ", "")
+}
+
+func TestDocsUIIsSelfContained(t *testing.T) {
+	cfg := config.Default()
+	cfg.DocsUI = true
+	router := routes.NewRouter(newDeps(cfg, &mail.MemoryMailer{}))
+
+	for path, contentType := range map[string]string{
+		"/v1/docs":                      "text/html",
+		"/v1/docs/swagger-ui.css":       "text/css",
+		"/v1/docs/swagger-ui-bundle.js": "text/javascript",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Header().Get("Content-Type"), contentType, path)
+		if path == "/v1/docs" {
+			assert.NotContains(t, w.Body.String(), "https://", "the page loads nothing from another site")
+		}
+	}
+}
+
+func TestUnversionedPathsAreDeprecated(t *testing.T) {
+	router := routes.NewRouter(newDeps(config.Default(), &mail.MemoryMailer{}))
+
+	for _, path := range []string{"/openapi.json", "/v1/openapi.json", "/health"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+
+		if path == "/openapi.json" {
+			assert.Regexp(t, `^@\d+$`, w.Header().Get("Deprecation"))
+			assert.Equal(t, `</v1/openapi.json>; rel="successor-version"`, w.Header().Get("Link"))
+		} else {
+			assert.Empty(t, w.Header().Get("Deprecation"), path)
+		}
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	cfg := config.Default()
+
+	_, err := newMailer(cfg)
+	assert.ErrorContains(t, err, "MAIL_DEV")
+
+	cfg.MailDev = true
+	mailer, err := newMailer(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &mail.MemoryMailer{}, mailer)
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	mailer, err = newMailer(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, mail.SMTPMailer{}, mailer)
+}
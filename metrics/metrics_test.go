@@ -0,0 +1,37 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(metrics.Middleware)
+	router.GET("/rounds/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	for _, path := range []string{"/rounds/1", "/rounds/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	metrics.ScoreSubmitted(15)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `boulder_http_requests_total{method="GET",route="/rounds/:id",status="204"} 2`)
+	assert.Contains(t, body, `boulder_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `boulder_http_request_duration_seconds_count{method="GET",route="/rounds/:id",status="204"} 2`)
+	assert.Contains(t, body, `boulder_scores_submitted_total{competition="15"} 1`)
+	assert.False(t, strings.Contains(body, "/rounds/1"), "paths aren't used as labels")
+}
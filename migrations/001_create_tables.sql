@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS competitions (
+	competition_id SERIAL PRIMARY KEY,
+	competition_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS competition_categories (
+	category_id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rounds (
+	round_id SERIAL PRIMARY KEY,
+	round_number INTEGER NOT NULL,
+	start_date TIMESTAMPTZ NOT NULL,
+	end_date TIMESTAMPTZ NOT NULL,
+	competition_id INTEGER NOT NULL REFERENCES competitions (competition_id)
+);
+
+CREATE TABLE IF NOT EXISTS competitors (
+	competitor_id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	password TEXT NOT NULL,
+	category_id INTEGER NOT NULL REFERENCES competition_categories (category_id)
+);
+
+CREATE TABLE IF NOT EXISTS boulder_problems (
+	problem_id SERIAL PRIMARY KEY,
+	round_id INTEGER NOT NULL REFERENCES rounds (round_id),
+	problem_number INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS scores (
+	score_id SERIAL PRIMARY KEY,
+	competitor_id INTEGER NOT NULL REFERENCES competitors (competitor_id),
+	problem_id INTEGER NOT NULL REFERENCES boulder_problems (problem_id),
+	attempts INTEGER NOT NULL,
+	points INTEGER NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Number of best rounds that count towards a competitor's total. NULL counts
+-- every round.
+ALTER TABLE competitions ADD COLUMN IF NOT EXISTS counted_rounds INTEGER CHECK (counted_rounds > 0);
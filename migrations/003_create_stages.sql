@@ -0,0 +1,25 @@
+ALTER TABLE rounds ADD COLUMN IF NOT EXISTS stage TEXT NOT NULL DEFAULT 'qualification'
+	CHECK (stage IN ('qualification', 'semi_final', 'final'));
+
+-- Quota and points rule for each stage after qualification.
+CREATE TABLE IF NOT EXISTS stages (
+	stage_id SERIAL PRIMARY KEY,
+	competition_id INTEGER NOT NULL REFERENCES competitions (competition_id),
+	stage TEXT NOT NULL CHECK (stage IN ('semi_final', 'final')),
+	quota INTEGER NOT NULL CHECK (quota > 0),
+	points_rule TEXT NOT NULL CHECK (points_rule IN ('carry_over', 'reset')),
+	UNIQUE (competition_id, stage)
+);
+
+-- Competitors who advanced into a stage, with their rank in the previous stage
+-- and the points they carry over into it.
+CREATE TABLE IF NOT EXISTS start_list_entries (
+	entry_id SERIAL PRIMARY KEY,
+	competition_id INTEGER NOT NULL REFERENCES competitions (competition_id),
+	stage TEXT NOT NULL,
+	category_id INTEGER NOT NULL REFERENCES competition_categories (category_id),
+	competitor_id INTEGER NOT NULL REFERENCES competitors (competitor_id),
+	position INTEGER NOT NULL,
+	carried_points INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (competition_id, stage, competitor_id)
+);
@@ -0,0 +1,14 @@
+-- Generated running order and time slots for a round and category.
+CREATE TABLE IF NOT EXISTS running_orders (
+	running_order_id SERIAL PRIMARY KEY,
+	round_id INTEGER NOT NULL REFERENCES rounds (round_id),
+	category_id INTEGER NOT NULL REFERENCES competition_categories (category_id),
+	competitor_id INTEGER NOT NULL REFERENCES competitors (competitor_id),
+	running_position INTEGER NOT NULL,
+	ranking INTEGER,
+	isolation_time TIMESTAMPTZ NOT NULL,
+	slot_start TIMESTAMPTZ NOT NULL,
+	slot_end TIMESTAMPTZ NOT NULL,
+	UNIQUE (round_id, category_id, competitor_id),
+	UNIQUE (round_id, category_id, running_position)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE boulder_problems
+	ADD COLUMN IF NOT EXISTS grade TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS colour TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS sector TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS setter TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS photo_url TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS has_zone BOOLEAN NOT NULL DEFAULT FALSE,
+	-- Points configuration for the scoring engine. NULL falls back to the
+	-- competition's defaults.
+	ADD COLUMN IF NOT EXISTS top_points INTEGER CHECK (top_points >= 0),
+	ADD COLUMN IF NOT EXISTS zone_points INTEGER CHECK (zone_points >= 0),
+	ADD COLUMN IF NOT EXISTS flash_bonus INTEGER CHECK (flash_bonus >= 0),
+	ADD COLUMN IF NOT EXISTS attempt_penalty INTEGER CHECK (attempt_penalty >= 0);
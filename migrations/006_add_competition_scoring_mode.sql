@@ -0,0 +1,5 @@
+-- Under dynamic scoring each top on a problem is worth points_pool divided by
+-- the number of competitors in the category who topped it.
+ALTER TABLE competitions
+	ADD COLUMN IF NOT EXISTS scoring_mode TEXT NOT NULL DEFAULT 'fixed' CHECK (scoring_mode IN ('fixed', 'dynamic')),
+	ADD COLUMN IF NOT EXISTS points_pool INTEGER NOT NULL DEFAULT 1000 CHECK (points_pool > 0);
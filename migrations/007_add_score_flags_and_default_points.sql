@@ -0,0 +1,16 @@
+ALTER TABLE scores
+	ADD COLUMN IF NOT EXISTS topped BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN IF NOT EXISTS zone BOOLEAN NOT NULL DEFAULT FALSE,
+	-- Set when an organiser entered the points by hand instead of the server
+	-- calculating them.
+	ADD COLUMN IF NOT EXISTS points_overridden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Scores entered before the flags existed counted any points as a top.
+UPDATE scores SET topped = TRUE WHERE points > 0;
+
+-- Default points configuration for problems that don't set their own.
+ALTER TABLE competitions
+	ADD COLUMN IF NOT EXISTS top_points INTEGER NOT NULL DEFAULT 100 CHECK (top_points >= 0),
+	ADD COLUMN IF NOT EXISTS zone_points INTEGER NOT NULL DEFAULT 50 CHECK (zone_points >= 0),
+	ADD COLUMN IF NOT EXISTS flash_bonus INTEGER NOT NULL DEFAULT 10 CHECK (flash_bonus >= 0),
+	ADD COLUMN IF NOT EXISTS attempt_penalty INTEGER NOT NULL DEFAULT 10 CHECK (attempt_penalty >= 0);
@@ -0,0 +1,10 @@
+ALTER TABLE competitions
+	ADD COLUMN IF NOT EXISTS tie_break TEXT NOT NULL DEFAULT 'none'
+		CHECK (tie_break IN ('none', 'most_tops', 'fewest_attempts')),
+	ADD COLUMN IF NOT EXISTS registration_opens_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS registration_closes_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS venue TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC',
+	ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+		CHECK (visibility IN ('public', 'private')),
+	ADD COLUMN IF NOT EXISTS max_attempts INTEGER CHECK (max_attempts > 0);
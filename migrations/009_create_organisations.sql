@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS organisations (
+	organisation_id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	slug TEXT NOT NULL UNIQUE
+);
+
+-- Organisers sign in with a token issued when they accept an invitation. Only
+-- SHA-256 hashes of tokens are stored.
+CREATE TABLE IF NOT EXISTS organisers (
+	organiser_id SERIAL PRIMARY KEY,
+	organisation_id INTEGER NOT NULL REFERENCES organisations (organisation_id),
+	name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organiser_invitations (
+	invitation_id SERIAL PRIMARY KEY,
+	organisation_id INTEGER NOT NULL REFERENCES organisations (organisation_id),
+	email TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	accepted_at TIMESTAMPTZ
+);
+
+-- Existing data moves into a default organisation.
+INSERT INTO organisations (name, slug) VALUES ('Default', 'default') ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE competitions ADD COLUMN IF NOT EXISTS organisation_id INTEGER REFERENCES organisations (organisation_id);
+ALTER TABLE competition_categories ADD COLUMN IF NOT EXISTS organisation_id INTEGER REFERENCES organisations (organisation_id);
+ALTER TABLE competitors ADD COLUMN IF NOT EXISTS organisation_id INTEGER REFERENCES organisations (organisation_id);
+
+UPDATE competitions SET organisation_id = (SELECT organisation_id FROM organisations WHERE slug = 'default') WHERE organisation_id IS NULL;
+UPDATE competition_categories SET organisation_id = (SELECT organisation_id FROM organisations WHERE slug = 'default') WHERE organisation_id IS NULL;
+UPDATE competitors SET organisation_id = (SELECT organisation_id FROM organisations WHERE slug = 'default') WHERE organisation_id IS NULL;
+
+ALTER TABLE competitions ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE competition_categories ALTER COLUMN organisation_id SET NOT NULL;
+ALTER TABLE competitors ALTER COLUMN organisation_id SET NOT NULL;
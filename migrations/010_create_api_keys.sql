@@ -0,0 +1,15 @@
+-- Scoped keys for machine clients such as scoring kiosks and display boards.
+-- Only SHA-256 hashes of keys are stored; the prefix identifies a key in lists.
+CREATE TABLE IF NOT EXISTS api_keys (
+	api_key_id SERIAL PRIMARY KEY,
+	organisation_id INTEGER NOT NULL REFERENCES organisations (organisation_id),
+	created_by INTEGER NOT NULL REFERENCES organisers (organiser_id),
+	name TEXT NOT NULL,
+	scope TEXT NOT NULL CHECK (scope IN ('read', 'scores')),
+	competition_id INTEGER REFERENCES competitions (competition_id),
+	key_prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ
+);
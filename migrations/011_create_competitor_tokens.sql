@@ -0,0 +1,13 @@
+ALTER TABLE competitors ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Single-use tokens for email verification and password resets. Only SHA-256
+-- hashes of tokens are stored.
+CREATE TABLE IF NOT EXISTS competitor_tokens (
+	token_id SERIAL PRIMARY KEY,
+	competitor_id INTEGER NOT NULL REFERENCES competitors (competitor_id),
+	purpose TEXT NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
@@ -0,0 +1,5 @@
+UPDATE competitors SET email = LOWER(TRIM(email));
+
+-- Fails if an organisation already has duplicate registrations; merge them
+-- before applying.
+CREATE UNIQUE INDEX IF NOT EXISTS competitors_organisation_email_key ON competitors (organisation_id, email);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Migrations are applied in order, so everything up to this one has run.
+-- Later migrations record their own version as their last statement.
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 13)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,11 @@
+-- A competitor has one score per problem; submitting another replaces it.
+-- Keep the newest of any duplicates entered before this was enforced.
+DELETE FROM scores older
+USING scores newer
+WHERE older.competitor_id = newer.competitor_id
+	AND older.problem_id = newer.problem_id
+	AND older.score_id < newer.score_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS scores_competitor_problem_key ON scores (competitor_id, problem_id);
+
+INSERT INTO schema_migrations (version) VALUES (14) ON CONFLICT (version) DO NOTHING;
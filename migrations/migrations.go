@@ -0,0 +1,34 @@
+// Package migrations embeds the SQL schema files so the server knows which
+// schema version it expects.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var Files embed.FS
+
+// Latest returns the version of the newest migration, taken from the number
+// at the start of its file name.
+func Latest() (int, error) {
+	names, err := fs.Glob(Files, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	latest := 0
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has no version number: %w", name, err)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
@@ -0,0 +1,38 @@
+package migrations_test
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/josenymad/boulder-api/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatest(t *testing.T) {
+	latest, err := migrations.Latest()
+	assert.NoError(t, err)
+
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	assert.NoError(t, err)
+	assert.Len(t, names, latest, "migrations are numbered from 1 without gaps or repeats")
+}
+
+func TestMigrationsRecordTheirVersion(t *testing.T) {
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	assert.NoError(t, err)
+
+	// 013 creates schema_migrations and records everything up to itself.
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 13 {
+			continue
+		}
+		migration, err := fs.ReadFile(migrations.Files, name)
+		assert.NoError(t, err)
+		assert.Contains(t, string(migration), fmt.Sprintf("INSERT INTO schema_migrations (version) VALUES (%d)", version), name)
+	}
+}
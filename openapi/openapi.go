@@ -0,0 +1,198 @@
+// Package openapi describes the API as an OpenAPI 3 document, with request
+// and response schemas generated from the types package.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Who can call an operation.
+const (
+	AccessPublic    = "public"
+	AccessTenant    = "tenant"
+	AccessScorer    = "scorer"
+	AccessOrganiser = "organiser"
+	AccessAdmin     = "admin"
+)
+
+// Operation documents one route. Path uses Gin's :param syntax. Request and
+// Response are zero values of the body types; a nil Response means the body
+// is a plain message.
+type Operation struct {
+	Method     string
+	Path       string
+	Tag        string
+	Summary    string
+	Access     string
+	Query      []Parameter
+	Request    interface{}
+	Status     int
+	Response   interface{}
+	Produces   string
+	Deprecated bool
+}
+
+type Parameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Message is the body of responses that only report what happened.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Error is the body of every error response. The request's ID is in the
+// X-Request-ID header.
+type Error struct {
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+var integerParameters = map[string]bool{"id": true, "round": true}
+
+// Document builds the OpenAPI document for operations.
+func Document(operations []Operation) map[string]interface{} {
+	schemas := map[string]Schema{}
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content":     jsonContent(schemaFor(reflect.TypeOf(Error{}), schemas)),
+	}
+
+	paths := map[string]map[string]interface{}{}
+	for _, operation := range operations {
+		path, parameters := convertPath(operation.Path)
+		for _, query := range operation.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        query.Name,
+				"in":          "query",
+				"description": query.Description,
+				"required":    query.Required,
+				"schema":      Schema{"type": "string"},
+			})
+		}
+
+		status := operation.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := operation.Response
+		if response == nil {
+			response = Message{}
+		}
+		content := jsonContent(schemaFor(reflect.TypeOf(response), schemas))
+		if operation.Produces != "" {
+			content = map[string]interface{}{operation.Produces: map[string]interface{}{"schema": Schema{"type": "string"}}}
+		}
+
+		item := map[string]interface{}{
+			"summary":     operation.Summary,
+			"operationId": operationID(operation),
+			"tags":        []string{operation.Tag},
+			"security":    security(operation.Access),
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): map[string]interface{}{
+					"description": http.StatusText(status),
+					"content":     content,
+				},
+				"default": errorResponse,
+			},
+		}
+		if len(parameters) > 0 {
+			item["parameters"] = parameters
+		}
+		if operation.Deprecated {
+			item["deprecated"] = true
+		}
+		if operation.Request != nil {
+			item["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaFor(reflect.TypeOf(operation.Request), schemas)),
+			}
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(operation.Method)] = item
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Bouldering Competition API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerToken": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "An organiser token, an API key starting bk_, or the admin token for /admin routes.",
+				},
+				"organisationHeader": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Organisation-ID",
+					"description": "The organisation read from, for callers without a token.",
+				},
+			},
+		},
+	}
+}
+
+func jsonContent(schema Schema) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// convertPath turns /rounds/:id into /rounds/{id} and describes its path
+// parameters.
+func convertPath(path string) (string, []interface{}) {
+	var parameters []interface{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		schema := Schema{"type": "string"}
+		if integerParameters[name] {
+			schema = Schema{"type": "integer"}
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+func operationID(operation Operation) string {
+	id := strings.ToLower(operation.Method)
+	for _, segment := range strings.Split(operation.Path, "/") {
+		segment = strings.TrimPrefix(segment, ":")
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+func security(access string) []map[string][]string {
+	switch access {
+	case AccessTenant:
+		return []map[string][]string{{"bearerToken": {}}, {"organisationHeader": {}}}
+	case AccessScorer, AccessOrganiser, AccessAdmin:
+		return []map[string][]string{{"bearerToken": {}}}
+	}
+	return []map[string][]string{}
+}
@@ -0,0 +1,40 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/josenymad/boulder-api/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument(t *testing.T) {
+	body, err := json.Marshal(openapi.Document(openapi.Operations))
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+
+	var document struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                   `json:"required"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+
+	assert.Contains(t, document.Paths, "/v1/rounds/{id}/stats")
+	assert.Contains(t, document.Paths["/v1/scores"], "post")
+	assert.ElementsMatch(t, []string{"attempts", "competitor_id", "problem_id"}, document.Components.Schemas["Score"].Required)
+	assert.JSONEq(t, `{"type": "string", "enum": ["read", "scores"]}`, string(document.Components.Schemas["APIKey"].Properties["scope"]))
+	assert.JSONEq(t, `{"type": "string", "format": "date-time", "nullable": true}`, string(document.Components.Schemas["APIKey"].Properties["revoked_at"]))
+
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(body), -1) {
+		assert.Contains(t, document.Components.Schemas, ref[1], "referenced schemas are defined")
+	}
+}
@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/josenymad/boulder-api/types"
+)
+
+// Operations documents every route the server registers. It is kept by hand;
+// the router tests fail when a route is missing from it or its request,
+// response or status differ from what the route's handler binds and writes. The deprecated
+// unversioned aliases of the /v1 routes aren't listed, apart from the renamed
+// routes that have no /v1 route at the same path.
+var Operations = []Operation{
+	{Method: "GET", Path: "/health", Tag: "health", Summary: "Report that the service is up", Access: AccessPublic},
+	{Method: "GET", Path: "/livez", Tag: "health", Summary: "Liveness probe", Access: AccessPublic, Response: types.Readiness{}},
+	{Method: "GET", Path: "/readyz", Tag: "health", Summary: "Readiness probe checking the database and schema version", Access: AccessPublic, Response: types.Readiness{}},
+	{Method: "GET", Path: "/metrics", Tag: "health", Summary: "Prometheus metrics", Access: AccessPublic, Produces: "text/plain"},
+	{Method: "GET", Path: "/v1/openapi.json", Tag: "docs", Summary: "This document", Access: AccessPublic, Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/v1/docs", Tag: "docs", Summary: "Interactive API documentation", Access: AccessPublic, Produces: "text/html"},
+	{Method: "GET", Path: "/v1/docs/swagger-ui.css", Tag: "docs", Summary: "Styles for the documentation page", Access: AccessPublic, Produces: "text/css"},
+	{Method: "GET", Path: "/v1/docs/swagger-ui-bundle.js", Tag: "docs", Summary: "Script for the documentation page", Access: AccessPublic, Produces: "text/javascript"},
+
+	{Method: "POST", Path: "/v1/invitations/:token/accept", Tag: "organisations", Summary: "Accept an organiser invitation and receive an organiser token", Access: AccessPublic,
+		Request: types.InvitationAcceptance{}, Status: http.StatusCreated, Response: types.Organiser{}},
+	{Method: "POST", Path: "/v1/email-verifications/:token", Tag: "accounts", Summary: "Verify a competitor's email address", Access: AccessPublic},
+	{Method: "POST", Path: "/v1/password-resets/:token", Tag: "accounts", Summary: "Set a new password with a reset token", Access: AccessPublic,
+		Request: types.PasswordReset{}},
+
+	{Method: "POST", Path: "/v1/admin/organisations", Tag: "admin", Summary: "Create an organisation", Access: AccessAdmin,
+		Request: types.Organisation{}, Status: http.StatusCreated, Response: types.Organisation{}},
+	{Method: "POST", Path: "/v1/admin/organisations/:id/invitations", Tag: "admin", Summary: "Invite an organiser to an organisation", Access: AccessAdmin,
+		Request: types.OrganiserInvitation{}, Status: http.StatusCreated, Response: types.OrganiserInvitation{}},
+	{Method: "GET", Path: "/v1/admin/organisations", Tag: "admin", Summary: "List organisations", Access: AccessAdmin, Response: []types.Organisation{}},
+
+	{Method: "POST", Path: "/v1/competition", Tag: "competitions", Summary: "Create a competition", Access: AccessOrganiser,
+		Request: types.NewCompetition{}, Status: http.StatusCreated, Response: types.Competition{}},
+	{Method: "GET", Path: "/v1/competitions", Tag: "competitions", Summary: "List competitions", Access: AccessTenant,
+		Query:    []Parameter{{Name: "include_private", Description: "Set to true to include private competitions; organisers and API keys only"}},
+		Response: []types.Competition{}},
+	{Method: "GET", Path: "/v1/competitions/:id/settings", Tag: "competitions", Summary: "Get a competition's settings", Access: AccessTenant,
+		Response: types.CompetitionSettings{}},
+	{Method: "PATCH", Path: "/v1/competitions/:id/settings", Tag: "competitions", Summary: "Change a competition's settings", Access: AccessOrganiser,
+		Request: types.CompetitionSettingsUpdate{}, Response: types.CompetitionSettings{}},
+
+	{Method: "POST", Path: "/v1/categories", Tag: "categories", Summary: "Create a category", Access: AccessOrganiser,
+		Request: types.Category{}, Status: http.StatusCreated, Response: types.Category{}},
+	{Method: "GET", Path: "/v1/categories", Tag: "categories", Summary: "List categories", Access: AccessTenant, Response: []types.Category{}},
+
+	{Method: "POST", Path: "/v1/rounds", Tag: "rounds", Summary: "Create a round", Access: AccessOrganiser,
+		Request: types.Round{}, Status: http.StatusCreated, Response: types.Round{}},
+	{Method: "GET", Path: "/v1/competitions/:id/rounds", Tag: "rounds", Summary: "List a competition's rounds", Access: AccessTenant,
+		Response: []types.Round{}},
+	{Method: "GET", Path: "/rounds/:id", Tag: "rounds", Summary: "Old name for GET /v1/competitions/{id}/rounds; id is the competition ID", Access: AccessTenant,
+		Response: []types.Round{}, Deprecated: true},
+	{Method: "GET", Path: "/v1/rounds/:id/stats", Tag: "rounds", Summary: "Get send and flash rates for a round's problems", Access: AccessTenant,
+		Response: types.RoundStats{}},
+
+	{Method: "POST", Path: "/v1/competitors", Tag: "competitors", Summary: "Register a competitor and send them a verification email", Access: AccessTenant,
+		Request: types.Competitor{}, Status: http.StatusCreated, Response: types.CompetitorResponse{}},
+	{Method: "GET", Path: "/v1/competitors", Tag: "competitors", Summary: "List competitors", Access: AccessTenant, Response: []types.Competitor{}},
+	{Method: "POST", Path: "/v1/competitors/:id/verification", Tag: "accounts", Summary: "Resend a competitor's verification email", Access: AccessTenant,
+		Status: http.StatusAccepted},
+	{Method: "POST", Path: "/v1/password-resets", Tag: "accounts", Summary: "Email a password reset code if the address is registered", Access: AccessTenant,
+		Request: types.PasswordResetRequest{}, Status: http.StatusAccepted},
+
+	{Method: "POST", Path: "/v1/boulder-problems", Tag: "boulder problems", Summary: "Create a boulder problem", Access: AccessOrganiser,
+		Request: types.BoulderProblem{}, Status: http.StatusCreated, Response: types.BoulderProblem{}},
+	{Method: "GET", Path: "/v1/rounds/:id/boulder-problems", Tag: "boulder problems", Summary: "List a round's boulder problems", Access: AccessTenant,
+		Response: []types.BoulderProblem{}},
+	{Method: "GET", Path: "/boulder-problems/:id", Tag: "boulder problems", Summary: "Old name for GET /v1/rounds/{id}/boulder-problems; id is the round ID", Access: AccessTenant,
+		Response: []types.BoulderProblem{}, Deprecated: true},
+	{Method: "PATCH", Path: "/v1/boulder-problems/:id", Tag: "boulder problems", Summary: "Change a boulder problem", Access: AccessOrganiser,
+		Request: types.BoulderProblemUpdate{}, Response: types.BoulderProblem{}},
+	{Method: "GET", Path: "/v1/boulder-problems/:id/stats", Tag: "boulder problems", Summary: "Get a boulder problem's send and flash rates", Access: AccessTenant,
+		Response: types.ProblemStats{}},
+
+	{Method: "POST", Path: "/v1/scores", Tag: "scores", Summary: "Submit a score, replacing any earlier one for the same problem; points are calculated unless an organiser sets override", Access: AccessScorer,
+		Request: types.Score{}, Status: http.StatusCreated, Response: types.Score{}},
+	{Method: "GET", Path: "/v1/scores", Tag: "scores", Summary: "Get a category's leaderboard", Access: AccessTenant,
+		Query: []Parameter{
+			{Name: "competition", Description: "Competition ID", Required: true},
+			{Name: "category", Description: "Category ID", Required: true},
+			{Name: "stage", Description: "Only count rounds in this stage, adding points carried over into it"},
+			{Name: "hide_non_starters", Description: "Set to true to leave out competitors without scores"},
+		},
+		Response: []types.TotalScore{}},
+
+	{Method: "POST", Path: "/v1/competitions/:id/stages", Tag: "stages", Summary: "Configure a stage's quota and points rule", Access: AccessOrganiser,
+		Request: types.StageConfig{}, Status: http.StatusCreated, Response: types.StageConfig{}},
+	{Method: "GET", Path: "/v1/competitions/:id/stages", Tag: "stages", Summary: "List a competition's stages", Access: AccessTenant,
+		Response: []types.StageConfig{}},
+	{Method: "POST", Path: "/v1/competitions/:id/stages/:stage/advance", Tag: "stages", Summary: "Fill the next stage's start list from a stage's leaderboard", Access: AccessOrganiser,
+		Status: http.StatusCreated, Response: []types.StartListEntry{}},
+	{Method: "GET", Path: "/v1/competitions/:id/stages/:stage/start-list", Tag: "stages", Summary: "Get the competitors who advanced into a stage", Access: AccessTenant,
+		Response: []types.StartListEntry{}},
+
+	{Method: "POST", Path: "/v1/start-lists/:round", Tag: "start lists", Summary: "Draw a round's running order and time slots for a category", Access: AccessOrganiser,
+		Request: types.StartListRequest{}, Status: http.StatusCreated, Response: []types.RunningOrderEntry{}},
+	{Method: "GET", Path: "/v1/start-lists/:round", Tag: "start lists", Summary: "Get a round's running order as JSON, or CSV with format=csv or Accept: text/csv", Access: AccessTenant,
+		Query: []Parameter{
+			{Name: "category", Description: "Only include this category"},
+			{Name: "format", Description: "Set to csv for a CSV download"},
+		},
+		Response: []types.RunningOrderEntry{}},
+
+	{Method: "POST", Path: "/v1/api-keys", Tag: "api keys", Summary: "Create an API key; the key is only shown in this response", Access: AccessOrganiser,
+		Request: types.APIKey{}, Status: http.StatusCreated, Response: types.APIKey{}},
+	{Method: "GET", Path: "/v1/api-keys", Tag: "api keys", Summary: "List API keys", Access: AccessOrganiser, Response: []types.APIKey{}},
+	{Method: "DELETE", Path: "/v1/api-keys/:id", Tag: "api keys", Summary: "Revoke an API key", Access: AccessOrganiser, Response: types.APIKey{}},
+}
@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verificationTokenLifetime  = 48 * time.Hour
+	passwordResetTokenLifetime = time.Hour
+)
+
+func sendVerificationEmail(ctx context.Context, mailer mail.Mailer, competitorID int, email string) error {
+	token, err := utils.CreateCompetitorToken(ctx, competitorID, types.TokenVerifyEmail, verificationTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Use this code to verify your email address:\n\n%s\n\nIt expires in %s.",
+			token, verificationTokenLifetime),
+	})
+}
+
+// POST
+
+func RequestEmailVerification(c *gin.Context) {
+	competitorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid competitor ID", "error": err.Error()})
+		return
+	}
+
+	var email string
+	var verifiedAt sql.NullTime
+	query := "SELECT email, email_verified_at FROM competitors WHERE competitor_id = $1 AND organisation_id = $2"
+	err = config.DB.QueryRowContext(c.Request.Context(), query, competitorID, organisationID(c)).Scan(&email, &verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Competitor not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competitor", "error": err.Error()})
+		return
+	}
+
+	if verifiedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	if err := sendVerificationEmail(c.Request.Context(), mailer(c), competitorID, email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to send verification email", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+func VerifyEmail(c *gin.Context) {
+	competitorID, err := utils.ConsumeCompetitorToken(c.Request.Context(), config.DB, c.Param("token"), types.TokenVerifyEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Verification token not found, expired or already used"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check verification token", "error": err.Error()})
+		return
+	}
+
+	query := "UPDATE competitors SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE competitor_id = $1"
+	if _, err := config.DB.ExecContext(c.Request.Context(), query, competitorID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to verify email", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// RequestPasswordReset emails a reset token to the competitor with the given
+// email address. It responds the same way whether or not the address is
+// registered, so it can't be used to find out who has an account.
+func RequestPasswordReset(c *gin.Context) {
+	var request types.PasswordResetRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind password reset JSON", "error": err.Error()})
+		return
+	}
+
+	email, err := utils.NormaliseEmail(request.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid email", "error": err.Error()})
+		return
+	}
+
+	var competitorID int
+	query := "SELECT competitor_id FROM competitors WHERE email = $1 AND organisation_id = $2"
+	err = config.DB.QueryRowContext(c.Request.Context(), query, email, organisationID(c)).Scan(&competitorID)
+	if err == nil {
+		err = sendPasswordResetEmail(c.Request.Context(), mailer(c), competitorID, email)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(c.Request.Context(), "Error sending password reset email", "error", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email address is registered, a password reset email has been sent"})
+}
+
+func sendPasswordResetEmail(ctx context.Context, mailer mail.Mailer, competitorID int, email string) error {
+	token, err := utils.CreateCompetitorToken(ctx, competitorID, types.TokenResetPassword, passwordResetTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this code to reset your password:\n\n%s\n\nIt expires in %s. If you didn't ask to reset your password, you can ignore this email.",
+			token, passwordResetTokenLifetime),
+	})
+}
+
+// ResetPassword sets a new password using a reset token, and cancels any other
+// reset tokens the competitor has outstanding.
+func ResetPassword(c *gin.Context) {
+	var reset types.PasswordReset
+	if err := c.BindJSON(&reset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind password reset JSON", "error": err.Error()})
+		return
+	}
+
+	// Hash first so the transaction isn't held open while bcrypt runs.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(reset.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to hash password", "error": err.Error()})
+		return
+	}
+
+	// The token is only used up if the password changes with it, and every
+	// other outstanding reset token is cancelled at the same time.
+	tx, err := config.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start transaction", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(c.Request.Context(), "Error rolling back password reset", "error", err)
+		}
+	}()
+
+	competitorID, err := utils.ConsumeCompetitorToken(c.Request.Context(), tx, c.Param("token"), types.TokenResetPassword)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Password reset token not found, expired or already used"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check password reset token", "error": err.Error()})
+		return
+	}
+
+	query := "UPDATE competitors SET password = $2 WHERE competitor_id = $1"
+	if _, err := tx.ExecContext(c.Request.Context(), query, competitorID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to reset password", "error": err.Error()})
+		return
+	}
+
+	query = "UPDATE competitor_tokens SET used_at = NOW() WHERE competitor_id = $1 AND purpose = $2 AND used_at IS NULL"
+	if _, err := tx.ExecContext(c.Request.Context(), query, competitorID, types.TokenResetPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to cancel other password reset tokens", "error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit password reset", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
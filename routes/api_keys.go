@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+)
+
+const apiKeyColumns = "api_key_id, name, scope, competition_id, key_prefix, created_at, last_used_at, revoked_at"
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, apiKey *types.APIKey) error {
+	return row.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Scope, &apiKey.CompetitionID, &apiKey.Prefix, &apiKey.CreatedAt,
+		&apiKey.LastUsedAt, &apiKey.RevokedAt)
+}
+
+// POST
+
+// CreateAPIKey creates a key for the organiser's organisation. The key itself
+// is only returned in this response.
+func CreateAPIKey(c *gin.Context) {
+	var apiKey types.APIKey
+	if err := c.BindJSON(&apiKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind API key JSON", "error": err.Error()})
+		return
+	}
+
+	if apiKey.CompetitionID != nil && !requireOwnership(c, utils.ResourceCompetition, *apiKey.CompetitionID) {
+		return
+	}
+
+	token, _, err := utils.GenerateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate API key", "error": err.Error()})
+		return
+	}
+	key := APIKeyPrefix + token
+
+	query := `INSERT INTO api_keys (organisation_id, created_by, name, scope, competition_id, key_prefix, key_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + apiKeyColumns
+	row := config.DB.QueryRowContext(c.Request.Context(), query, organisationID(c), c.GetInt(OrganiserKey), apiKey.Name, apiKey.Scope, apiKey.CompetitionID,
+		key[:len(APIKeyPrefix)+8], utils.HashToken(key))
+	if err := scanAPIKey(row, &apiKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create API key", "error": err.Error()})
+		return
+	}
+	apiKey.Key = key
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// GET
+
+func GetAllAPIKeys(c *gin.Context) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE organisation_id = $1 ORDER BY api_key_id"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, organisationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get API keys", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error closing API key rows", "error", err)
+		}
+	}()
+
+	var apiKeys []types.APIKey
+	for rows.Next() {
+		var apiKey types.APIKey
+		if err := scanAPIKey(rows, &apiKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan API key rows", "error": err.Error()})
+			return
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over API key rows", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// DELETE
+
+// RevokeAPIKey stops a key from working. Revoked keys stay listed so their
+// usage can still be audited.
+func RevokeAPIKey(c *gin.Context) {
+	apiKeyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid API key ID", "error": err.Error()})
+		return
+	}
+
+	var apiKey types.APIKey
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE api_key_id = $1 AND organisation_id = $2
+		RETURNING ` + apiKeyColumns
+	err = scanAPIKey(config.DB.QueryRowContext(c.Request.Context(), query, apiKeyID, organisationID(c)), &apiKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "API key not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to revoke API key", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKey)
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/openapi"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+var openAPIDocument = sync.OnceValue(func() map[string]interface{} {
+	return openapi.Document(openapi.Operations)
+})
+
+// GET
+
+func GetOpenAPIDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPIDocument())
+}
+
+// GetDocs serves Swagger UI for the OpenAPI document. Its script and styles
+// come from the swaggo/files module, pinned in go.sum, and are served by
+// GetDocsAsset rather than loaded from a CDN.
+func GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+// GetDocsAsset serves one of Swagger UI's files.
+func GetDocsAsset(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.FileFromFS(name, http.FS(swaggerFiles.FS))
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Bouldering Competition API</title>
+	<link rel="stylesheet" href="docs/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="docs/swagger-ui-bundle.js"></script>
+	<script>
+		SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});
+	</script>
+</body>
+</html>
+`
@@ -0,0 +1,179 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josenymad/boulder-api/types"
+)
+
+const testAdminToken = "test-admin-token"
+
+// client sends requests through the router with the same headers each time.
+type client struct {
+	t      *testing.T
+	router http.Handler
+	header http.Header
+}
+
+// do sends body as JSON and decodes the response into out unless out is nil.
+func (c client) do(method string, path string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("Failed to marshal JSON: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			c.t.Fatalf("Failed to unmarshal JSON from %s %s: %v", method, path, err)
+		}
+	}
+	return w
+}
+
+// create POSTs body to path and decodes the response into out, failing the
+// test unless the response is 201 Created.
+func (c client) create(path string, body interface{}, out interface{}) {
+	c.t.Helper()
+	if w := c.do("POST", path, body, out); w.Code != http.StatusCreated {
+		c.t.Fatalf("POST %s returned %d: %s", path, w.Code, w.Body)
+	}
+}
+
+// get GETs path and decodes the response into out, failing the test unless
+// the response is 200 OK.
+func (c client) get(path string, out interface{}) {
+	c.t.Helper()
+	if w := c.do("GET", path, nil, out); w.Code != http.StatusOK {
+		c.t.Fatalf("GET %s returned %d: %s", path, w.Code, w.Body)
+	}
+}
+
+// fixture is a competition in an organisation of its own, so tests can assert
+// on whole lists. It has three finished qualification rounds of one problem
+// each; only the first problem has a zone. With the default points (top 100,
+// zone 50, flash bonus 10, attempt penalty 10) the competitors score:
+//
+//	         round 1      round 2      round 3      total
+//	Alex     flash 110    -            2 tries 90   200
+//	Brooke   3 tries 80   flash 110    -            190
+//	Chris    zone 50      4 tries 70   flash 110    230
+//	Dana     -            -            -            0
+type fixture struct {
+	organiser    client
+	organisation types.Organisation
+	competition  types.Competition
+	category     types.Category
+	rounds       []types.Round
+	problems     []types.BoulderProblem
+	competitors  map[string]types.CompetitorResponse
+}
+
+func newFixture(t *testing.T, router http.Handler) fixture {
+	admin := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + testAdminToken}}}
+
+	var f fixture
+	slug := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	admin.create("/v1/admin/organisations", types.Organisation{Name: "Test Organisation", Slug: slug}, &f.organisation)
+
+	var invitation types.OrganiserInvitation
+	admin.create(fmt.Sprintf("/v1/admin/organisations/%d/invitations", f.organisation.ID), types.OrganiserInvitation{Email: "organiser@mail.com"}, &invitation)
+	var organiser types.Organiser
+	admin.create("/v1/invitations/"+invitation.Token+"/accept", types.InvitationAcceptance{Name: "Test Organiser"}, &organiser)
+	f.organiser = client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + organiser.Token}}}
+
+	f.organiser.create("/v1/competition", types.NewCompetition{Name: "Test Competition"}, &f.competition)
+	f.organiser.create("/v1/categories", types.Category{Name: "Test Category"}, &f.category)
+
+	start := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+	for number := 1; number <= 3; number++ {
+		var round types.Round
+		f.organiser.create("/v1/rounds", types.Round{
+			Number:        number,
+			StartDate:     start.AddDate(0, 0, 7*(number-1)),
+			EndDate:       start.AddDate(0, 0, 7*(number-1)).Add(8 * time.Hour),
+			CompetitionID: f.competition.ID,
+		}, &round)
+		f.rounds = append(f.rounds, round)
+
+		var problem types.BoulderProblem
+		f.organiser.create("/v1/boulder-problems", types.BoulderProblem{Number: 1, RoundID: round.ID, Grade: "6B+", HasZone: number == 1}, &problem)
+		f.problems = append(f.problems, problem)
+	}
+
+	f.competitors = make(map[string]types.CompetitorResponse)
+	for _, name := range []string{"Alex", "Brooke", "Chris", "Dana"} {
+		var competitor types.CompetitorResponse
+		f.organiser.create("/v1/competitors", types.Competitor{
+			Name:       name,
+			Email:      name + "@mail.com",
+			Password:   "test_password",
+			CategoryID: f.category.ID,
+		}, &competitor)
+		f.competitors[name] = competitor
+	}
+
+	for _, score := range []struct {
+		name     string
+		round    int
+		attempts int
+		topped   bool
+		zone     bool
+	}{
+		{"Alex", 1, 1, true, true},
+		{"Alex", 3, 2, true, false},
+		{"Brooke", 1, 3, true, true},
+		{"Brooke", 2, 1, true, false},
+		{"Chris", 1, 2, false, true},
+		{"Chris", 2, 4, true, false},
+		{"Chris", 3, 1, true, false},
+	} {
+		f.organiser.create("/v1/scores", types.Score{
+			Attempts:     score.attempts,
+			Topped:       score.topped,
+			Zone:         score.zone,
+			CompetitorID: f.competitors[score.name].ID,
+			ProblemID:    f.problems[score.round-1].ID,
+		}, nil)
+	}
+
+	return f
+}
+
+// leaderboard GETs the leaderboard for the fixture's category with the extra
+// query string, which should start with & if it isn't empty.
+func (f fixture) leaderboard(query string) []types.TotalScore {
+	var totalScores []types.TotalScore
+	f.organiser.get(fmt.Sprintf("/v1/scores?competition=%d&category=%d%s", f.competition.ID, f.category.ID, query), &totalScores)
+	return totalScores
+}
+
+// names lists the competitor names in a leaderboard, in order.
+func names(totalScores []types.TotalScore) []string {
+	var names []string
+	for _, totalScore := range totalScores {
+		names = append(names, totalScore["competitor_name"].(string))
+	}
+	return names
+}
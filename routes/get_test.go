@@ -0,0 +1,490 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josenymad/boulder-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLeaderboard(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	totalScores := f.leaderboard("")
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke", "Dana"}, names(totalScores))
+
+	expected := map[string]map[string]interface{}{
+		"Alex": {"total": 200.0, "tops": 2.0, "top_attempts": 3.0,
+			"round_1": 110.0, "round_2": 0.0, "round_3": 90.0,
+			"round_1_dns": false, "round_2_dns": true, "round_3_dns": false},
+		"Brooke": {"total": 190.0, "tops": 2.0, "top_attempts": 4.0,
+			"round_1": 80.0, "round_2": 110.0, "round_3": 0.0,
+			"round_1_dns": false, "round_2_dns": false, "round_3_dns": true},
+		"Chris": {"total": 230.0, "tops": 2.0, "top_attempts": 5.0,
+			"round_1": 50.0, "round_2": 70.0, "round_3": 110.0,
+			"round_1_dns": false, "round_2_dns": false, "round_3_dns": false},
+		"Dana": {"total": 0.0, "tops": 0.0, "top_attempts": 0.0,
+			"round_1": 0.0, "round_2": 0.0, "round_3": 0.0,
+			"round_1_dns": true, "round_2_dns": true, "round_3_dns": true},
+	}
+	for _, totalScore := range totalScores {
+		name := totalScore["competitor_name"].(string)
+		assert.Equal(t, float64(f.competitors[name].ID), totalScore["competitor_id"])
+		for column, value := range expected[name] {
+			assert.Equal(t, value, totalScore[column], "%s's %s", name, column)
+		}
+	}
+}
+
+func TestGetLeaderboardHideNonStarters(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	// Dana hasn't started, so is only listed when non-starters are shown.
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke", "Dana"}, names(f.leaderboard("")))
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke", "Dana"}, names(f.leaderboard("&hide_non_starters=false")))
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke"}, names(f.leaderboard("&hide_non_starters=true")))
+
+	// Any score counts as starting, even one worth nothing.
+	f.organiser.create("/v1/scores", types.Score{Attempts: 3, CompetitorID: f.competitors["Dana"].ID, ProblemID: f.problems[1].ID}, nil)
+	totalScores := f.leaderboard("&hide_non_starters=true")
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke", "Dana"}, names(totalScores))
+	assert.Equal(t, 0.0, totalScores[3]["total"])
+	assert.Equal(t, false, totalScores[3]["round_2_dns"])
+}
+
+func TestGetLeaderboardCountedRounds(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	w := f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), map[string]interface{}{"counted_rounds": 2}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Chris loses the 50 from round 1 and drops from first to third.
+	totalScores := f.leaderboard("")
+	assert.Equal(t, []string{"Alex", "Brooke", "Chris", "Dana"}, names(totalScores))
+	expected := map[string][]interface{}{
+		"Alex":   {200.0, []interface{}{2.0}},
+		"Brooke": {190.0, []interface{}{3.0}},
+		"Chris":  {180.0, []interface{}{1.0}},
+		"Dana":   {0.0, []interface{}{3.0}},
+	}
+	for _, totalScore := range totalScores {
+		name := totalScore["competitor_name"].(string)
+		assert.Equal(t, expected[name][0], totalScore["total"], "%s's total", name)
+		assert.Equal(t, expected[name][1], totalScore["dropped_rounds"], "%s's dropped rounds", name)
+	}
+}
+
+func TestGetLeaderboardDynamicScoring(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	w := f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), map[string]interface{}{"scoring_mode": "dynamic", "points_pool": 1000}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	totals := func() map[string]interface{} {
+		totals := make(map[string]interface{})
+		for _, totalScore := range f.leaderboard("") {
+			totals[totalScore["competitor_name"].(string)] = totalScore["total"]
+		}
+		return totals
+	}
+
+	// Every problem has two toppers, so each top is worth 500.
+	assert.Equal(t, map[string]interface{}{"Alex": 1000.0, "Brooke": 1000.0, "Chris": 1000.0, "Dana": 0.0}, totals())
+
+	// Dana is the only one to top a new problem and takes the whole pool.
+	var problem types.BoulderProblem
+	f.organiser.create("/v1/boulder-problems", types.BoulderProblem{Number: 2, RoundID: f.rounds[0].ID, Grade: "7A"}, &problem)
+	f.organiser.create("/v1/scores", types.Score{Attempts: 1, Topped: true, CompetitorID: f.competitors["Dana"].ID, ProblemID: problem.ID}, nil)
+	assert.Equal(t, map[string]interface{}{"Alex": 1000.0, "Brooke": 1000.0, "Chris": 1000.0, "Dana": 1000.0}, totals())
+
+	// A third top of the first problem cuts Alex's and Brooke's share to 333.
+	f.organiser.create("/v1/scores", types.Score{Attempts: 2, Topped: true, CompetitorID: f.competitors["Dana"].ID, ProblemID: f.problems[0].ID}, nil)
+	expected := map[string]interface{}{"Alex": 833.0, "Brooke": 833.0, "Chris": 1000.0, "Dana": 1333.0}
+	assert.Equal(t, expected, totals())
+
+	// Resubmitting a top replaces the score instead of adding another topper.
+	f.organiser.create("/v1/scores", types.Score{Attempts: 2, Topped: true, CompetitorID: f.competitors["Alex"].ID, ProblemID: f.problems[0].ID}, nil)
+	assert.Equal(t, expected, totals())
+}
+
+func TestGetLeaderboardDynamicOverride(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	w := f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), map[string]interface{}{"scoring_mode": "dynamic", "points_pool": 1000}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Dana's overridden top keeps its points but still counts as a third
+	// topper of the second problem.
+	f.organiser.create("/v1/scores", types.Score{
+		Attempts:     1,
+		Topped:       true,
+		Points:       250,
+		Override:     true,
+		CompetitorID: f.competitors["Dana"].ID,
+		ProblemID:    f.problems[1].ID,
+	}, nil)
+
+	totals := make(map[string]interface{})
+	for _, totalScore := range f.leaderboard("") {
+		totals[totalScore["competitor_name"].(string)] = totalScore["total"]
+	}
+	assert.Equal(t, map[string]interface{}{"Alex": 1000.0, "Brooke": 833.0, "Chris": 833.0, "Dana": 250.0}, totals)
+}
+
+func TestGetStages(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var stage types.StageConfig
+	f.organiser.create(fmt.Sprintf("/v1/competitions/%d/stages", f.competition.ID), types.StageConfig{Stage: types.StageSemiFinal, Quota: 2, PointsRule: types.PointsRuleCarryOver}, &stage)
+	var advanced []types.StartListEntry
+	f.organiser.create(fmt.Sprintf("/v1/competitions/%d/stages/%s/advance", f.competition.ID, types.StageQualification), nil, &advanced)
+
+	var stages []types.StageConfig
+	f.organiser.get(fmt.Sprintf("/v1/competitions/%d/stages", f.competition.ID), &stages)
+	assert.Equal(t, []types.StageConfig{stage}, stages)
+
+	var startList []types.StartListEntry
+	f.organiser.get(fmt.Sprintf("/v1/competitions/%d/stages/%s/start-list", f.competition.ID, types.StageSemiFinal), &startList)
+	assert.Equal(t, advanced, startList)
+	if assert.Len(t, startList, 2) {
+		assert.Equal(t, f.competitors["Chris"].ID, startList[0].CompetitorID)
+		assert.Equal(t, 1, startList[0].Position)
+		assert.Equal(t, int64(230), startList[0].CarriedPoints)
+		assert.Equal(t, f.competitors["Alex"].ID, startList[1].CompetitorID)
+		assert.Equal(t, 2, startList[1].Position)
+		assert.Equal(t, int64(200), startList[1].CarriedPoints)
+	}
+
+	start := time.Date(2024, time.July, 29, 10, 0, 0, 0, time.UTC)
+	f.organiser.create("/v1/rounds", types.Round{Number: 4, StartDate: start, EndDate: start.Add(8 * time.Hour), CompetitionID: f.competition.ID, Stage: types.StageSemiFinal}, nil)
+
+	// The semi-final round hasn't been climbed, so the carried points are the
+	// whole total, and only it gets a column.
+	totalScores := f.leaderboard("&stage=" + types.StageSemiFinal)
+	assert.Equal(t, []string{"Chris", "Alex"}, names(totalScores))
+	for _, totalScore := range totalScores {
+		assert.Equal(t, totalScore["carried_points"], totalScore["total"])
+		assert.Contains(t, totalScore, "round_4")
+		assert.NotContains(t, totalScore, "round_1")
+	}
+}
+
+func TestGetStartList(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	path := fmt.Sprintf("/v1/start-lists/%d", f.rounds[0].ID)
+	var generated []types.RunningOrderEntry
+	f.organiser.create(path, types.StartListRequest{
+		CategoryID:  f.category.ID,
+		Order:       types.OrderReverseRank,
+		StartTime:   time.Date(2024, time.August, 1, 9, 0, 0, 0, time.UTC),
+		SlotMinutes: 5,
+	}, &generated)
+
+	var entries []types.RunningOrderEntry
+	f.organiser.get(path, &entries)
+	assert.Len(t, entries, len(generated))
+
+	// Unranked competitors go first, then the leaderboard from the bottom.
+	var order []string
+	for _, entry := range entries {
+		order = append(order, entry.CompetitorName)
+	}
+	assert.Equal(t, []string{"Dana", "Brooke", "Alex", "Chris"}, order)
+	for index, entry := range entries {
+		assert.Equal(t, index+1, entry.Position)
+		assert.True(t, entry.SlotStart.Equal(generated[index].SlotStart))
+	}
+
+	w := f.organiser.do("GET", path+"?format=csv", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), len(entries)+1)
+
+	for accept, contentType := range map[string]string{
+		"text/csv":                         "text/csv",
+		"text/csv; charset=utf-8":          "text/csv",
+		"text/csv, application/json;q=0.5": "text/csv",
+		"application/json, text/csv":       "application/json; charset=utf-8",
+		"*/*":                              "application/json; charset=utf-8",
+	} {
+		caller := client{t: t, router: router, header: f.organiser.header.Clone()}
+		caller.header.Set("Accept", accept)
+		w := caller.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, contentType, w.Header().Get("Content-Type"), accept)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	expected := types.ProblemStats{
+		ProblemID:       f.problems[0].ID,
+		Number:          1,
+		RoundID:         f.rounds[0].ID,
+		Attempted:       3,
+		Sends:           2,
+		Flashes:         1,
+		SendRate:        2.0 / 3.0,
+		FlashRate:       1.0 / 3.0,
+		AverageAttempts: 2,
+		Categories: []types.CategoryStats{
+			{CategoryID: f.category.ID, Name: f.category.Name, Attempted: 3, Sends: 2, Flashes: 1},
+		},
+	}
+
+	var problemStats types.ProblemStats
+	f.organiser.get(fmt.Sprintf("/v1/boulder-problems/%d/stats", f.problems[0].ID), &problemStats)
+	assert.Equal(t, expected, problemStats)
+
+	var roundStats types.RoundStats
+	f.organiser.get(fmt.Sprintf("/v1/rounds/%d/stats", f.rounds[0].ID), &roundStats)
+	assert.Equal(t, types.RoundStats{RoundID: f.rounds[0].ID, Problems: []types.ProblemStats{expected}}, roundStats)
+
+	for _, path := range []string{"/v1/boulder-problems/first/stats", "/v1/rounds/first/stats"} {
+		w := f.organiser.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestGetCompetitions(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var competitions []types.Competition
+	f.organiser.get("/v1/competitions", &competitions)
+	assert.Equal(t, []types.Competition{f.competition}, competitions)
+
+	var settings types.CompetitionSettings
+	f.organiser.get(fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), &settings)
+	assert.Equal(t, f.competition.Settings, settings)
+
+	w := f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), map[string]interface{}{"visibility": types.VisibilityPrivate}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var apiKey types.APIKey
+	f.organiser.create("/v1/api-keys", types.APIKey{Name: "Display board", Scope: types.ScopeRead}, &apiKey)
+	board := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + apiKey.Key}}}
+
+	// Private competitions are only listed for organisers and API keys that
+	// ask for them.
+	visitor := client{t: t, router: router, header: http.Header{"X-Organisation-ID": {fmt.Sprint(f.organisation.ID)}}}
+	visitor.get("/v1/competitions?include_private=true", &competitions)
+	assert.Empty(t, competitions)
+	f.organiser.get("/v1/competitions", &competitions)
+	assert.Empty(t, competitions)
+	for _, caller := range []client{f.organiser, board} {
+		caller.get("/v1/competitions?include_private=true", &competitions)
+		if assert.Len(t, competitions, 1) {
+			assert.Equal(t, f.competition.ID, competitions[0].ID)
+		}
+	}
+
+	// Nor are their resources served to anyone without a token.
+	for _, path := range []string{
+		fmt.Sprintf("/v1/competitions/%d/rounds", f.competition.ID),
+		fmt.Sprintf("/v1/boulder-problems/%d/stats", f.problems[0].ID),
+		fmt.Sprintf("/v1/competitions/%d/stages", f.competition.ID),
+		fmt.Sprintf("/v1/start-lists/%d", f.rounds[0].ID),
+		fmt.Sprintf("/v1/scores?competition=%d&category=%d", f.competition.ID, f.category.ID),
+	} {
+		w := visitor.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		w = f.organiser.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		w = board.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+func TestGetCompetitionResources(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var categories []types.Category
+	f.organiser.get("/v1/categories", &categories)
+	assert.Equal(t, []types.Category{f.category}, categories)
+
+	var rounds []types.Round
+	f.organiser.get(fmt.Sprintf("/v1/competitions/%d/rounds", f.competition.ID), &rounds)
+	if assert.Len(t, rounds, len(f.rounds)) {
+		for index, round := range rounds {
+			assert.Equal(t, f.rounds[index].ID, round.ID)
+			assert.Equal(t, f.rounds[index].Number, round.Number)
+			assert.Equal(t, types.StageQualification, round.Stage)
+			assert.True(t, f.rounds[index].StartDate.Equal(round.StartDate))
+			assert.True(t, f.rounds[index].EndDate.Equal(round.EndDate))
+		}
+	}
+
+	for index, round := range f.rounds {
+		var problems []types.BoulderProblem
+		f.organiser.get(fmt.Sprintf("/v1/rounds/%d/boulder-problems", round.ID), &problems)
+		assert.Equal(t, []types.BoulderProblem{f.problems[index]}, problems)
+	}
+
+	var competitors []types.Competitor
+	f.organiser.get("/v1/competitors", &competitors)
+	var expected []types.Competitor
+	for _, competitor := range f.competitors {
+		expected = append(expected, types.Competitor{ID: competitor.ID, Name: competitor.Name, CategoryID: competitor.CategoryID})
+	}
+	assert.ElementsMatch(t, expected, competitors)
+}
+
+func TestGetFromAnotherOrganisation(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	other := client{t: t, router: router, header: http.Header{"X-Organisation-ID": {"1"}}}
+	for _, path := range []string{
+		fmt.Sprintf("/v1/competitions/%d/rounds", f.competition.ID),
+		fmt.Sprintf("/v1/rounds/%d/stats", f.rounds[0].ID),
+		fmt.Sprintf("/v1/rounds/%d/boulder-problems", f.rounds[0].ID),
+		fmt.Sprintf("/v1/boulder-problems/%d/stats", f.problems[0].ID),
+		fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID),
+		fmt.Sprintf("/v1/competitions/%d/stages", f.competition.ID),
+		fmt.Sprintf("/v1/start-lists/%d", f.rounds[0].ID),
+		fmt.Sprintf("/v1/scores?competition=%d&category=%d", f.competition.ID, f.category.ID),
+	} {
+		w := other.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestGetFromAnotherCompetition(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var other types.Competition
+	f.organiser.create("/v1/competition", types.NewCompetition{Name: "Other Competition"}, &other)
+	var apiKey types.APIKey
+	f.organiser.create("/v1/api-keys", types.APIKey{Name: "Other scoreboard", Scope: types.ScopeScores, CompetitionID: &other.ID}, &apiKey)
+	scoreboard := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + apiKey.Key}}}
+
+	var competitions []types.Competition
+	scoreboard.get("/v1/competitions", &competitions)
+	if assert.Len(t, competitions, 1) {
+		assert.Equal(t, other.ID, competitions[0].ID)
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/v1/competitions/%d/rounds", f.competition.ID),
+		fmt.Sprintf("/v1/rounds/%d/stats", f.rounds[0].ID),
+		fmt.Sprintf("/v1/rounds/%d/boulder-problems", f.rounds[0].ID),
+		fmt.Sprintf("/v1/boulder-problems/%d/stats", f.problems[0].ID),
+		fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID),
+		fmt.Sprintf("/v1/competitions/%d/stages", f.competition.ID),
+		fmt.Sprintf("/v1/competitions/%d/stages/qualification/start-list", f.competition.ID),
+		fmt.Sprintf("/v1/start-lists/%d", f.rounds[0].ID),
+		fmt.Sprintf("/v1/scores?competition=%d&category=%d", f.competition.ID, f.category.ID),
+	} {
+		w := scoreboard.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+	}
+
+	w := scoreboard.do("POST", "/v1/scores", types.Score{Attempts: 1, CompetitorID: f.competitors["Dana"].ID, ProblemID: f.problems[0].ID}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetInvalidIDs(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	for _, path := range []string{
+		fmt.Sprintf("/v1/scores?category=%d", f.category.ID),
+		fmt.Sprintf("/v1/scores?competition=first&category=%d", f.category.ID),
+		"/v1/competitions/first/settings",
+		"/v1/competitions/first/rounds",
+		"/v1/rounds/first/boulder-problems",
+		"/v1/start-lists/first",
+	} {
+		w := f.organiser.do("GET", path, nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var created types.APIKey
+	f.organiser.create("/v1/api-keys", types.APIKey{Name: "Scoreboard", Scope: types.ScopeRead, CompetitionID: &f.competition.ID}, &created)
+
+	var apiKeys []types.APIKey
+	f.organiser.get("/v1/api-keys", &apiKeys)
+	if assert.Len(t, apiKeys, 1) {
+		assert.Equal(t, created.ID, apiKeys[0].ID)
+		assert.Equal(t, created.Prefix, apiKeys[0].Prefix)
+		assert.Empty(t, apiKeys[0].Key)
+		assert.Nil(t, apiKeys[0].LastUsedAt)
+	}
+
+	scoreboard := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + created.Key}}}
+	var totalScores []types.TotalScore
+	scoreboard.get(fmt.Sprintf("/v1/scores?competition=%d&category=%d", f.competition.ID, f.category.ID), &totalScores)
+	assert.Equal(t, []string{"Chris", "Alex", "Brooke", "Dana"}, names(totalScores))
+
+	// API keys can't list keys, whatever their scope.
+	w := scoreboard.do("GET", "/v1/api-keys", nil, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetOrganisations(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	admin := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + testAdminToken}}}
+	var organisations []types.Organisation
+	admin.get("/v1/admin/organisations", &organisations)
+	assert.Contains(t, organisations, f.organisation)
+
+	w := f.organiser.do("GET", "/v1/admin/organisations", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestGetHealth(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	anyone := client{t: t, router: router}
+
+	var health map[string]string
+	anyone.get("/health", &health)
+	assert.Equal(t, "Service is healthy", health["message"])
+
+	var liveness, readiness types.Readiness
+	anyone.get("/livez", &liveness)
+	assert.Equal(t, types.HealthOK, liveness.Status)
+	anyone.get("/readyz", &readiness)
+	assert.Equal(t, types.HealthOK, readiness.Status)
+	assert.Equal(t, types.HealthOK, readiness.Checks["database"].Status)
+	assert.Equal(t, types.HealthOK, readiness.Checks["migrations"].Status)
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/migrations"
+	"github.com/josenymad/boulder-api/types"
+)
+
+// readinessTimeout bounds how long the readiness checks can wait on Postgres.
+const readinessTimeout = 2 * time.Second
+
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown makes the readiness probe fail, so load balancers stop
+// sending new requests while the server drains.
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// TraceFilter keeps probe and metrics scrapes out of traces.
+func TraceFilter(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/health", "/livez", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
+
+// GET
+
+// Livez reports that the process is up. It doesn't check dependencies, so a
+// database outage doesn't get the server restarted.
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, types.Readiness{Status: types.HealthOK})
+}
+
+// Readyz reports whether the server can handle requests: it isn't shutting
+// down, Postgres answers and the schema is at the version this build expects.
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	readiness := types.Readiness{
+		Status: types.HealthOK,
+		Checks: map[string]types.HealthCheck{
+			"shutdown":   checkShutdown(),
+			"database":   checkDatabase(ctx),
+			"migrations": checkMigrations(ctx),
+		},
+	}
+
+	status := http.StatusOK
+	for _, check := range readiness.Checks {
+		if check.Status != types.HealthOK {
+			readiness.Status = types.HealthUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(status, readiness)
+}
+
+func checkShutdown() types.HealthCheck {
+	if shuttingDown.Load() {
+		return types.HealthCheck{Status: types.HealthUnavailable, Error: "server is shutting down"}
+	}
+	return types.HealthCheck{Status: types.HealthOK}
+}
+
+func checkDatabase(ctx context.Context) types.HealthCheck {
+	if err := config.DB.PingContext(ctx); err != nil {
+		return types.HealthCheck{Status: types.HealthUnavailable, Error: err.Error()}
+	}
+	return types.HealthCheck{Status: types.HealthOK}
+}
+
+func checkMigrations(ctx context.Context) types.HealthCheck {
+	expected, err := migrations.Latest()
+	if err != nil {
+		return types.HealthCheck{Status: types.HealthUnavailable, Error: err.Error()}
+	}
+
+	var version int
+	query := "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"
+	if err := config.DB.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return types.HealthCheck{Status: types.HealthUnavailable, Error: err.Error()}
+	}
+
+	// A newer schema is fine: during a rolling deploy the new release migrates
+	// the database while instances of this one are still serving.
+	check := types.HealthCheck{Status: types.HealthOK, Version: &version}
+	if version < expected {
+		check.Status = types.HealthUnavailable
+		check.Error = fmt.Sprintf("schema is at version %d, expected %d", version, expected)
+	}
+	return check
+}
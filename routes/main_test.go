@@ -0,0 +1,46 @@
+package routes_test
+
+import (
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/josenymad/boulder-api/testdb"
+)
+
+// databaseAvailable is false when no test database is configured, in which
+// case only the tests that don't need one run.
+var databaseAvailable bool
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	cleanup, err := testdb.Setup("testdata/seed.sql")
+	if errors.Is(err, testdb.ErrNotConfigured) && !testdb.Required() {
+		log.Printf("Skipping database tests: %v", err)
+		return m.Run()
+	}
+	if err != nil {
+		log.Printf("Could not set up test database: %v", err)
+		return 1
+	}
+	defer func() {
+		if err := cleanup(); err != nil {
+			log.Printf("Could not clean up test database: %v", err)
+		}
+	}()
+
+	databaseAvailable = true
+	return m.Run()
+}
+
+// requireDB skips the test when there is no test database.
+func requireDB(t *testing.T) {
+	t.Helper()
+	if !databaseAvailable {
+		t.Skip("no test database configured")
+	}
+}
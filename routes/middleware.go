@@ -0,0 +1,341 @@
+package routes
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/logging"
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+)
+
+// Context keys set by TenantMiddleware.
+const (
+	OrganisationKey      = "organisation_id"
+	OrganiserKey         = "organiser_id"
+	APIKeyKey            = "api_key_id"
+	APIKeyScopeKey       = "api_key_scope"
+	APIKeyCompetitionKey = "api_key_competition_id"
+)
+
+// MailerKey holds the mail.Mailer from Deps, set by MailerMiddleware.
+const MailerKey = "mailer"
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// APIKeyPrefix starts every API key, telling them apart from organiser tokens.
+const APIKeyPrefix = "bk_"
+
+// RequestIDMiddleware gives every request an ID, reusing the caller's
+// X-Request-ID when it is a sensible one. The ID is carried by the request's
+// context into log lines and sent back in the X-Request-ID header.
+func RequestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader(RequestIDHeader)
+	if !validRequestID.MatchString(requestID) {
+		generated, _, err := utils.GenerateToken()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate request ID", "error": err.Error()})
+			return
+		}
+		requestID = generated[:32]
+	}
+
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+	c.Header(RequestIDHeader, requestID)
+	c.Next()
+}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestLogger logs a line for every request once it has been handled.
+func RequestLogger(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	level := slog.LevelInfo
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.LogAttrs(c.Request.Context(), level, "Request handled",
+		slog.String("method", c.Request.Method),
+		slog.String("route", c.FullPath()),
+		slog.String("path", c.Request.URL.Path),
+		slog.Int("status", c.Writer.Status()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("client_ip", c.ClientIP()),
+	)
+}
+
+// Recovery turns a panicking handler into a 500 response and logs the panic.
+var Recovery = gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+	slog.ErrorContext(c.Request.Context(), "Panic handling request", "panic", recovered, "stack", string(debug.Stack()))
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Internal server error"})
+})
+
+// TenantMiddleware resolves the organisation a request belongs to. Organisers
+// and API keys are identified by their bearer token; anyone else names the
+// organisation they are reading from in the X-Organisation-ID header.
+func TenantMiddleware(c *gin.Context) {
+	if token := bearerToken(c); strings.HasPrefix(token, APIKeyPrefix) {
+		authenticateAPIKey(c, token)
+		return
+	} else if token != "" {
+		var organiserID, organisationID int
+		query := "SELECT organiser_id, organisation_id FROM organisers WHERE token_hash = $1"
+		err := config.DB.QueryRowContext(c.Request.Context(), query, utils.HashToken(token)).Scan(&organiserID, &organisationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid token", "error": "no organiser has this token"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to check token", "error": err.Error()})
+			return
+		}
+
+		c.Set(OrganiserKey, organiserID)
+		c.Set(OrganisationKey, organisationID)
+		c.Next()
+		return
+	}
+
+	organisationID, err := strconv.Atoi(c.GetHeader("X-Organisation-ID"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Organisation required", "error": "send an organiser token or an X-Organisation-ID header"})
+		return
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM organisations WHERE organisation_id = $1)"
+	if err := config.DB.QueryRowContext(c.Request.Context(), query, organisationID).Scan(&exists); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to get organisation", "error": err.Error()})
+		return
+	}
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "Organisation not found"})
+		return
+	}
+
+	c.Set(OrganisationKey, organisationID)
+	c.Next()
+}
+
+// authenticateAPIKey resolves an API key, records that it was used and checks
+// that its scope allows the request.
+func authenticateAPIKey(c *gin.Context, key string) {
+	var apiKeyID, organisationID int
+	var scope string
+	var competitionID sql.NullInt64
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING api_key_id, organisation_id, scope, competition_id`
+	err := config.DB.QueryRowContext(c.Request.Context(), query, utils.HashToken(key)).Scan(&apiKeyID, &organisationID, &scope, &competitionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid API key", "error": "the key does not exist or has been revoked"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Failed to check API key", "error": err.Error()})
+		return
+	}
+
+	if !apiKeyAllows(scope, c.Request.Method, c.FullPath()) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "API key scope does not allow this request", "error": "scope is " + scope})
+		return
+	}
+
+	c.Set(APIKeyKey, apiKeyID)
+	c.Set(APIKeyScopeKey, scope)
+	c.Set(OrganisationKey, organisationID)
+	if competitionID.Valid {
+		c.Set(APIKeyCompetitionKey, int(competitionID.Int64))
+	}
+	c.Next()
+}
+
+func apiKeyAllows(scope string, method string, path string) bool {
+	if method == http.MethodGet {
+		return true
+	}
+	return scope == types.ScopeScores && method == http.MethodPost && unversioned(path) == "/scores"
+}
+
+// RequireCredentials rejects requests without a bearer token before
+// TenantMiddleware can fall back to the X-Organisation-ID header, which is only
+// enough to read and to register as a competitor.
+func RequireCredentials(c *gin.Context) {
+	if bearerToken(c) == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Organiser token or API key required"})
+		return
+	}
+	c.Next()
+}
+
+// RequireOrganiser only lets through requests made with an organiser token.
+func RequireOrganiser(c *gin.Context) {
+	if _, ok := c.Get(OrganiserKey); !ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Organiser token required"})
+		return
+	}
+	c.Next()
+}
+
+// AdminMiddleware only lets through requests bearing adminToken. Admin routes
+// are disabled when it is empty.
+func AdminMiddleware(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware rejects requests with 429 once the caller has used up
+// their bucket in limiter. Callers are told apart by API key, then organiser,
+// then client IP, so it should run after TenantMiddleware where one applies.
+func RateLimitMiddleware(limiter *utils.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(rateLimitKey(c), time.Now())
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Too many requests", "error": fmt.Sprintf("try again in %d seconds", seconds)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// MailerMiddleware makes mailer available to handlers that send email.
+func MailerMiddleware(mailer mail.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(MailerKey, mailer)
+		c.Next()
+	}
+}
+
+// QueryTimeoutMiddleware gives each request's context a deadline, so queries
+// made with it are cancelled once the request has run for timeout. A timeout
+// of 0 leaves requests without a deadline.
+func QueryTimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if apiKeyID, ok := c.Get(APIKeyKey); ok {
+		return fmt.Sprintf("api_key:%d", apiKeyID)
+	}
+	if organiserID, ok := c.Get(OrganiserKey); ok {
+		return fmt.Sprintf("organiser:%d", organiserID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func bearerToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func organisationID(c *gin.Context) int {
+	return c.GetInt(OrganisationKey)
+}
+
+func mailer(c *gin.Context) mail.Mailer {
+	return c.MustGet(MailerKey).(mail.Mailer)
+}
+
+// requireOwnership responds with 404 and returns false unless the resource
+// belongs to the caller's organisation, so other tenants' IDs look the same as
+// IDs that don't exist. IDs taken from the path or query string are checked
+// to be numbers first, with 400 if they aren't. It also applies
+// requireCompetitionAccess.
+func requireOwnership(c *gin.Context, resource string, id interface{}) bool {
+	if text, ok := id.(string); ok {
+		parsed, err := strconv.Atoi(text)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid " + resource + " ID", "error": err.Error()})
+			return false
+		}
+		id = parsed
+	}
+
+	belongs, err := utils.BelongsToOrganisation(c.Request.Context(), resource, id, organisationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check " + resource, "error": err.Error()})
+		return false
+	}
+	if !belongs {
+		c.JSON(http.StatusNotFound, gin.H{"message": strings.ToUpper(resource[:1]) + resource[1:] + " not found"})
+		return false
+	}
+	return requireCompetitionAccess(c, resource, id)
+}
+
+// requireCompetitionAccess checks the caller can reach the competition a
+// resource is part of. API keys restricted to another competition get 403,
+// and callers without a token get 404 for private competitions, the same as
+// GetAllCompetitions hiding them. Organisers and API keys can see private
+// competitions.
+func requireCompetitionAccess(c *gin.Context, resource string, id interface{}) bool {
+	keyCompetition, restricted := c.Get(APIKeyCompetitionKey)
+	anonymous := bearerToken(c) == ""
+	if !restricted && !anonymous {
+		return true
+	}
+
+	competition, ok, err := utils.GetResourceCompetition(c.Request.Context(), resource, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check " + resource + " competition", "error": err.Error()})
+		return false
+	}
+	if !ok {
+		return true
+	}
+
+	if restricted && competition != keyCompetition.(int) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "API key is restricted to another competition"})
+		return false
+	}
+	if anonymous {
+		visibility, err := utils.GetCompetitionVisibility(c.Request.Context(), competition)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check competition visibility", "error": err.Error()})
+			return false
+		}
+		if visibility == types.VisibilityPrivate {
+			c.JSON(http.StatusNotFound, gin.H{"message": strings.ToUpper(resource[:1]) + resource[1:] + " not found"})
+			return false
+		}
+	}
+	return true
+}
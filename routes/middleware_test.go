@@ -0,0 +1,39 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/logging"
+	"github.com/josenymad/boulder-api/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(routes.RequestIDMiddleware)
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id_in_context": logging.RequestID(c.Request.Context())})
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Not found"})
+	})
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(routes.RequestIDHeader, "client-id.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "client-id.1", w.Header().Get(routes.RequestIDHeader))
+	assert.JSONEq(t, `{"request_id_in_context": "client-id.1"}`, w.Body.String())
+
+	req = httptest.NewRequest("GET", "/fail", nil)
+	req.Header.Set(routes.RequestIDHeader, "not a valid id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	requestID := w.Header().Get(routes.RequestIDHeader)
+	assert.Len(t, requestID, 32)
+
+	assert.JSONEq(t, `{"message": "Not found"}`, w.Body.String(), "error bodies are left as the handler wrote them")
+}
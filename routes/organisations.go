@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+	"github.com/lib/pq"
+)
+
+const invitationLifetime = 7 * 24 * time.Hour
+
+// POST
+
+func CreateOrganisation(c *gin.Context) {
+	var organisation types.Organisation
+	if err := c.BindJSON(&organisation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind organisation JSON", "error": err.Error()})
+		return
+	}
+
+	if !utils.ValidSlug(organisation.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid organisation slug", "error": "slug must be lowercase letters and numbers separated by hyphens"})
+		return
+	}
+
+	query := "INSERT INTO organisations (name, slug) VALUES ($1, $2) RETURNING organisation_id"
+
+	err := config.DB.QueryRowContext(c.Request.Context(), query, organisation.Name, organisation.Slug).Scan(&organisation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create organisation", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, organisation)
+}
+
+// CreateOrganiserInvitation invites someone to organise for an organisation.
+// The invitation token is only returned in this response.
+func CreateOrganiserInvitation(c *gin.Context) {
+	var invitation types.OrganiserInvitation
+	if err := c.BindJSON(&invitation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind invitation JSON", "error": err.Error()})
+		return
+	}
+
+	organisationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid organisation ID", "error": err.Error()})
+		return
+	}
+	invitation.OrganisationID = organisationID
+	invitation.ExpiresAt = time.Now().Add(invitationLifetime).UTC()
+
+	token, tokenHash, err := utils.GenerateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create invitation token", "error": err.Error()})
+		return
+	}
+	invitation.Token = token
+
+	query := "INSERT INTO organiser_invitations (organisation_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING invitation_id"
+
+	err = config.DB.QueryRowContext(c.Request.Context(), query, invitation.OrganisationID, invitation.Email, tokenHash, invitation.ExpiresAt).Scan(&invitation.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Organisation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create invitation", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// AcceptInvitation turns an unexpired invitation into an organiser and returns
+// the organiser's token. Each invitation can only be accepted once.
+func AcceptInvitation(c *gin.Context) {
+	var acceptance types.InvitationAcceptance
+	if err := c.BindJSON(&acceptance); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind invitation acceptance JSON", "error": err.Error()})
+		return
+	}
+
+	tx, err := config.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start transaction", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(c.Request.Context(), "Error rolling back invitation acceptance", "error", err)
+		}
+	}()
+
+	organiser := types.Organiser{Name: acceptance.Name}
+	query := `UPDATE organiser_invitations SET accepted_at = NOW()
+		WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING organisation_id, email`
+	err = tx.QueryRowContext(c.Request.Context(), query, utils.HashToken(c.Param("token"))).Scan(&organiser.OrganisationID, &organiser.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Invitation not found, expired or already accepted"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to accept invitation", "error": err.Error()})
+		return
+	}
+
+	token, tokenHash, err := utils.GenerateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create organiser token", "error": err.Error()})
+		return
+	}
+	organiser.Token = token
+
+	query = "INSERT INTO organisers (organisation_id, name, email, token_hash) VALUES ($1, $2, $3, $4) RETURNING organiser_id"
+	err = tx.QueryRowContext(c.Request.Context(), query, organiser.OrganisationID, organiser.Name, organiser.Email, tokenHash).Scan(&organiser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create organiser", "error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit invitation acceptance", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, organiser)
+}
+
+// GET
+
+func GetAllOrganisations(c *gin.Context) {
+	query := "SELECT organisation_id, name, slug FROM organisations ORDER BY organisation_id"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get organisations", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error closing organisation rows", "error", err)
+		}
+	}()
+
+	var organisations []types.Organisation
+	for rows.Next() {
+		var organisation types.Organisation
+		if err := rows.Scan(&organisation.ID, &organisation.Name, &organisation.Slug); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan organisation rows", "error": err.Error()})
+			return
+		}
+		organisations = append(organisations, organisation)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over organisation rows", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, organisations)
+}
@@ -0,0 +1,176 @@
+package routes
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/mail"
+	"github.com/josenymad/boulder-api/metrics"
+	"github.com/josenymad/boulder-api/tracing"
+	"github.com/josenymad/boulder-api/utils"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// APIVersion prefixes every API route. The same routes are still served
+// without it, marked deprecated, until clients have moved over.
+const APIVersion = "/v1"
+
+// unversionedDeprecation is when the unversioned paths were deprecated, in
+// the Deprecation header's format (RFC 9745).
+var unversionedDeprecation = fmt.Sprintf("@%d", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC).Unix())
+
+// Deps holds what the router needs beyond the global database. Mailer sends
+// account emails and must be set. A nil limiter turns that rate limit off, as
+// does a zero QueryTimeout. Client IPs are only taken from X-Forwarded-For
+// when the request comes from one of TrustedProxies. An empty AdminToken
+// turns the admin routes off.
+type Deps struct {
+	Mailer         mail.Mailer
+	AuthLimiter    *utils.RateLimiter
+	ScoresLimiter  *utils.RateLimiter
+	QueryTimeout   time.Duration
+	DocsUI         bool
+	TrustedProxies []string
+	AdminToken     string
+}
+
+// NewRouter builds the router the server runs, with its middleware and every
+// route. Tests use it too, so they exercise the same stack.
+func NewRouter(deps Deps) *gin.Engine {
+	router := gin.New()
+	// Gin trusts every proxy by default, which would let clients pick the IP
+	// they are rate limited by. config.Validate has checked the list, but
+	// fall back to trusting no proxy rather than all of them.
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		slog.Error("Invalid trusted proxies, trusting none", "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(TraceFilter)))
+	router.Use(RequestIDMiddleware, RequestLogger, Recovery)
+	router.Use(metrics.Middleware)
+	router.Use(QueryTimeoutMiddleware(deps.QueryTimeout), MailerMiddleware(deps.Mailer))
+
+	Register(router, deps)
+	return router
+}
+
+// Register adds every route to router: health checks and metrics at the
+// root, the API under APIVersion and its deprecated unversioned aliases.
+func Register(router *gin.Engine, deps Deps) {
+	router.GET("/health", HealthCheckHandler)
+	router.GET("/livez", Livez)
+	router.GET("/readyz", Readyz)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Both sets of paths share the limiters, so switching versions doesn't
+	// refill anyone's bucket.
+	authLimit := rateLimit(deps.AuthLimiter)
+	scoresLimit := rateLimit(deps.ScoresLimiter)
+	registerAPI(router.Group(APIVersion), deps, authLimit, scoresLimit)
+	unversionedAPI := router.Group("/", Deprecated)
+	registerAPI(unversionedAPI, deps, authLimit, scoresLimit)
+
+	// These list routes took the ID of the parent resource, so :id meant
+	// something different from the routes below them. APIVersion renames
+	// them; the old paths are only kept as unversioned aliases.
+	unversionedAPI.GET("/rounds/:id", renamed("/competitions/:id/rounds"), TenantMiddleware, GetAllRounds)
+	unversionedAPI.GET("/boulder-problems/:id", renamed("/rounds/:id/boulder-problems"), TenantMiddleware, GetBoulderProblems)
+}
+
+func registerAPI(api *gin.RouterGroup, deps Deps, authLimit gin.HandlerFunc, scoresLimit gin.HandlerFunc) {
+	api.GET("/openapi.json", GetOpenAPIDocument)
+	if deps.DocsUI {
+		api.GET("/docs", GetDocs)
+		api.GET("/docs/swagger-ui.css", GetDocsAsset("swagger-ui.css"))
+		api.GET("/docs/swagger-ui-bundle.js", GetDocsAsset("swagger-ui-bundle.js"))
+	}
+
+	// Registration and account routes hash passwords or check secret tokens,
+	// so they get a much lower limit than score submission.
+	auth := api.Group("/", authLimit)
+	auth.POST("/invitations/:token/accept", AcceptInvitation)
+	auth.POST("/email-verifications/:token", VerifyEmail)
+	auth.POST("/password-resets/:token", ResetPassword)
+
+	admin := api.Group("/admin", AdminMiddleware(deps.AdminToken))
+	admin.POST("/organisations", CreateOrganisation)
+	admin.POST("/organisations/:id/invitations", CreateOrganiserInvitation)
+	admin.GET("/organisations", GetAllOrganisations)
+
+	// The X-Organisation-ID header is enough to read and to register as a
+	// competitor. Everything else needs an organiser token, or a scores API
+	// key to submit scores.
+	tenant := api.Group("/", TenantMiddleware)
+	tenant.GET("/competitions", GetAllCompetitions)
+	tenant.GET("/categories", GetAllCategories)
+	tenant.GET("/boulder-problems/:id/stats", GetBoulderProblemStats)
+	tenant.GET("/rounds/:id/boulder-problems", GetBoulderProblems)
+	tenant.GET("/rounds/:id/stats", GetRoundStats)
+	tenant.GET("/competitions/:id/rounds", GetAllRounds)
+	tenant.GET("/competitors", GetAllCompetitors)
+	tenant.GET("/start-lists/:round", GetStartList)
+	tenant.GET("/scores", GetAllScores)
+	tenant.GET("/competitions/:id/stages", GetStages)
+	tenant.GET("/competitions/:id/stages/:stage/start-list", GetStageStartList)
+	tenant.GET("/competitions/:id/settings", GetCompetitionSettings)
+
+	tenantAuth := tenant.Group("/", authLimit)
+	tenantAuth.POST("/competitors", CreateCompetitor)
+	tenantAuth.POST("/competitors/:id/verification", RequestEmailVerification)
+	tenantAuth.POST("/password-resets", RequestPasswordReset)
+
+	authenticated := api.Group("/", RequireCredentials, TenantMiddleware)
+	scores := authenticated.Group("/", scoresLimit)
+	scores.POST("/scores", CreateScore)
+
+	organiser := authenticated.Group("/", RequireOrganiser)
+	organiser.POST("/competition", CreateCompetition)
+	organiser.POST("/categories", CreateCompetitionCategory)
+	organiser.POST("/rounds", CreateRound)
+	organiser.POST("/boulder-problems", CreateBoulderProblem)
+	organiser.POST("/competitions/:id/stages", CreateStage)
+	organiser.POST("/competitions/:id/stages/:stage/advance", AdvanceStage)
+	organiser.POST("/start-lists/:round", GenerateStartList)
+	organiser.PATCH("/boulder-problems/:id", UpdateBoulderProblem)
+	organiser.PATCH("/competitions/:id/settings", UpdateCompetitionSettings)
+	organiser.POST("/api-keys", CreateAPIKey)
+	organiser.GET("/api-keys", GetAllAPIKeys)
+	organiser.DELETE("/api-keys/:id", RevokeAPIKey)
+}
+
+func rateLimit(limiter *utils.RateLimiter) gin.HandlerFunc {
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return RateLimitMiddleware(limiter)
+}
+
+// Deprecated marks responses from an unversioned path as deprecated and
+// links to the versioned path that replaces it.
+func Deprecated(c *gin.Context) {
+	c.Header("Deprecation", unversionedDeprecation)
+	c.Header("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", APIVersion, c.Request.URL.Path))
+	c.Next()
+}
+
+// renamed links a deprecated alias to the versioned path that replaces it,
+// which takes the same :id.
+func renamed(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := strings.Replace(successor, ":id", c.Param("id"), 1)
+		c.Header("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", APIVersion, path))
+		c.Next()
+	}
+}
+
+// unversioned strips APIVersion from a route path, so checks on paths cover
+// both the versioned route and its alias.
+func unversioned(path string) string {
+	if rest, ok := strings.CutPrefix(path, APIVersion+"/"); ok {
+		return "/" + rest
+	}
+	return path
+}
@@ -1,16 +1,30 @@
 package routes
 
 import (
-	"log"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/metrics"
+	"github.com/josenymad/boulder-api/tracing"
 	"github.com/josenymad/boulder-api/types"
 	"github.com/josenymad/boulder-api/utils"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Postgres error codes for constraint failures.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
 func HealthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Service is healthy",
@@ -20,20 +34,49 @@ func HealthCheckHandler(c *gin.Context) {
 // POST
 
 func CreateCompetition(c *gin.Context) {
-	var competition types.Competition
-	if err := c.BindJSON(&competition); err != nil {
+	var newCompetition types.NewCompetition
+	if err := c.BindJSON(&newCompetition); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind competition JSON", "error": err.Error()})
 		return
 	}
 
-	query := `INSERT INTO competitions (competition_name) VALUES ($1) RETURNING competition_id`
+	tx, err := config.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start transaction", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(c.Request.Context(), "Error rolling back competition", "error", err)
+		}
+	}()
+
+	// Insert first so the settings that weren't sent take the column defaults.
+	competition := types.Competition{Name: newCompetition.Name}
+	query := `INSERT INTO competitions (competition_name, organisation_id) VALUES ($1, $2) RETURNING competition_id, ` + utils.CompetitionSettingsColumns
 
-	err := config.DB.QueryRow(query, competition.Name).Scan(&competition.ID)
+	err = utils.ScanCompetitionSettings(tx.QueryRowContext(c.Request.Context(), query, competition.Name, organisationID(c)), &competition.Settings, &competition.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create competition", "error": err.Error()})
 		return
 	}
 
+	utils.ApplySettingsUpdate(&competition.Settings, newCompetition.Settings)
+	if err := utils.ValidateSettings(competition.Settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid competition settings", "error": err.Error()})
+		return
+	}
+
+	if err := utils.SaveCompetitionSettings(c.Request.Context(), tx, strconv.Itoa(competition.ID), competition.Settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save competition settings", "error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit competition", "error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusCreated, competition)
 }
 
@@ -44,9 +87,9 @@ func CreateCompetitionCategory(c *gin.Context) {
 		return
 	}
 
-	query := "INSERT INTO competition_categories (name) VALUES ($1) RETURNING category_id"
+	query := "INSERT INTO competition_categories (name, organisation_id) VALUES ($1, $2) RETURNING category_id"
 
-	err := config.DB.QueryRow(query, category.Name).Scan(&category.ID)
+	err := config.DB.QueryRowContext(c.Request.Context(), query, category.Name, organisationID(c)).Scan(&category.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create competition category", "error": err.Error()})
 		return
@@ -62,9 +105,17 @@ func CreateRound(c *gin.Context) {
 		return
 	}
 
-	query := "INSERT INTO rounds (round_number, start_date, end_date, competition_id) VALUES ($1, $2, $3, $4) RETURNING round_id"
+	if !requireOwnership(c, utils.ResourceCompetition, round.CompetitionID) {
+		return
+	}
+
+	if round.Stage == "" {
+		round.Stage = types.StageQualification
+	}
+
+	query := "INSERT INTO rounds (round_number, start_date, end_date, competition_id, stage) VALUES ($1, $2, $3, $4, $5) RETURNING round_id"
 
-	err := config.DB.QueryRow(query, round.Number, round.StartDate, round.EndDate, round.CompetitionID).Scan(&round.ID)
+	err := config.DB.QueryRowContext(c.Request.Context(), query, round.Number, round.StartDate, round.EndDate, round.CompetitionID, round.Stage).Scan(&round.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create round", "error": err.Error()})
 		return
@@ -80,6 +131,40 @@ func CreateCompetitor(c *gin.Context) {
 		return
 	}
 
+	email, err := utils.NormaliseEmail(competitor.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid competitor email", "error": err.Error()})
+		return
+	}
+	competitor.Email = email
+
+	if !requireOwnership(c, utils.ResourceCategory, competitor.CategoryID) {
+		return
+	}
+
+	windows, err := utils.GetRegistrationWindows(c.Request.Context(), organisationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check registration", "error": err.Error()})
+		return
+	}
+	// Competitors register with the organisation, so they can while any of its
+	// competitions is taking registrations, or at any time before it has
+	// competitions.
+	if open, next := utils.RegistrationOpen(windows, time.Now()); !open {
+		reason := "registration has closed for every competition"
+		if next != nil {
+			reason = "registration opens at " + next.Format("2 January 2006 15:04 MST")
+		}
+		c.JSON(http.StatusForbidden, gin.H{"message": "Registration is closed", "error": reason})
+		return
+	}
+
+	// Check before hashing the password so duplicate registrations are cheap
+	// to turn away. The unique index still catches concurrent registrations.
+	if respondIfCompetitorExists(c, competitor.Email) {
+		return
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(competitor.Password), bcrypt.DefaultCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to hash password", "error": err.Error()})
@@ -88,14 +173,22 @@ func CreateCompetitor(c *gin.Context) {
 
 	competitor.Password = string(hashedPassword)
 
-	query := "INSERT INTO competitors (name, email, password, category_id) VALUES ($1, $2, $3, $4) RETURNING competitor_id"
+	query := "INSERT INTO competitors (name, email, password, category_id, organisation_id) VALUES ($1, $2, $3, $4, $5) RETURNING competitor_id"
 
-	err = config.DB.QueryRow(query, competitor.Name, competitor.Email, competitor.Password, competitor.CategoryID).Scan(&competitor.ID)
+	err = config.DB.QueryRowContext(c.Request.Context(), query, competitor.Name, competitor.Email, competitor.Password, competitor.CategoryID, organisationID(c)).Scan(&competitor.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && respondIfCompetitorExists(c, competitor.Email) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create competitor", "error": err.Error()})
 		return
 	}
 
+	if err := sendVerificationEmail(c.Request.Context(), mailer(c), competitor.ID, competitor.Email); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error sending verification email", "competitor_id", competitor.ID, "error", err)
+	}
+
 	response := types.CompetitorResponse{
 		ID:         competitor.ID,
 		Name:       competitor.Name,
@@ -105,6 +198,25 @@ func CreateCompetitor(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// respondIfCompetitorExists responds with 409 and the existing competitor, and
+// returns true, if the email address is already registered with the caller's
+// organisation.
+func respondIfCompetitorExists(c *gin.Context, email string) bool {
+	var existing types.CompetitorResponse
+	query := "SELECT competitor_id, name, category_id FROM competitors WHERE email = $1 AND organisation_id = $2"
+	err := config.DB.QueryRowContext(c.Request.Context(), query, email, organisationID(c)).Scan(&existing.ID, &existing.Name, &existing.CategoryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to check for existing competitor", "error": err.Error()})
+		return true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"message": "A competitor with this email is already registered", "competitor": existing})
+	return true
+}
+
 func CreateBoulderProblem(c *gin.Context) {
 	var boulderProblem types.BoulderProblem
 	if err := c.BindJSON(&boulderProblem); err != nil {
@@ -112,9 +224,17 @@ func CreateBoulderProblem(c *gin.Context) {
 		return
 	}
 
-	query := "INSERT INTO boulder_problems (round_id, problem_number) VALUES ($1, $2) RETURNING problem_id"
+	if !requireOwnership(c, utils.ResourceRound, boulderProblem.RoundID) {
+		return
+	}
+
+	query := `INSERT INTO boulder_problems (round_id, problem_number, grade, colour, sector, setter, photo_url, has_zone,
+			top_points, zone_points, flash_bonus, attempt_penalty)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING problem_id`
 
-	err := config.DB.QueryRow(query, boulderProblem.RoundID, boulderProblem.Number).Scan(&boulderProblem.ID)
+	err := config.DB.QueryRowContext(c.Request.Context(), query, boulderProblem.RoundID, boulderProblem.Number, boulderProblem.Grade, boulderProblem.Colour,
+		boulderProblem.Sector, boulderProblem.Setter, boulderProblem.PhotoURL, boulderProblem.HasZone, boulderProblem.Points.Top,
+		boulderProblem.Points.Zone, boulderProblem.Points.FlashBonus, boulderProblem.Points.AttemptPenalty).Scan(&boulderProblem.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create boulder problem", "error": err.Error()})
 		return
@@ -130,13 +250,55 @@ func CreateScore(c *gin.Context) {
 		return
 	}
 
-	query := "INSERT INTO scores (competitor_id, problem_id, attempts, points) VALUES ($1, $2, $3, $4) RETURNING score_id"
+	if score.Points != 0 && !score.Override {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Points are calculated by the server", "error": "points may only be sent with override"})
+		return
+	}
+	if _, isOrganiser := c.Get(OrganiserKey); score.Override && !isOrganiser {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Only organisers can override points"})
+		return
+	}
+
+	if !requireOwnership(c, utils.ResourceCompetitor, score.CompetitorID) || !requireOwnership(c, utils.ResourceBoulderProblem, score.ProblemID) {
+		return
+	}
+
+	problemCompetition, err := utils.GetProblemCompetition(c.Request.Context(), score.ProblemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get boulder problem competition", "error": err.Error()})
+		return
+	}
 
-	err := config.DB.QueryRow(query, score.CompetitorID, score.ProblemID, score.Attempts, score.Points).Scan(&score.ID)
+	pointsConfig, err := utils.GetPointsConfig(c.Request.Context(), score.ProblemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get points configuration", "error": err.Error()})
+		return
+	}
+
+	if pointsConfig.MaxAttempts > 0 && score.Attempts > pointsConfig.MaxAttempts {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Too many attempts", "error": fmt.Sprintf("attempts may not exceed %d", pointsConfig.MaxAttempts)})
+		return
+	}
+
+	if !score.Override {
+		score.Points = utils.CalculatePoints(score, pointsConfig)
+	}
+
+	// A competitor has one score per problem, so a resubmitted score replaces
+	// the earlier one instead of counting twice.
+	query := `INSERT INTO scores (competitor_id, problem_id, attempts, topped, zone, points, points_overridden)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (competitor_id, problem_id) DO UPDATE SET
+			attempts = EXCLUDED.attempts, topped = EXCLUDED.topped, zone = EXCLUDED.zone,
+			points = EXCLUDED.points, points_overridden = EXCLUDED.points_overridden
+		RETURNING score_id`
+
+	err = config.DB.QueryRowContext(c.Request.Context(), query, score.CompetitorID, score.ProblemID, score.Attempts, score.Topped, score.Zone, score.Points, score.Override).Scan(&score.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create score", "error": err.Error()})
 		return
 	}
+	metrics.ScoreSubmitted(problemCompetition)
 
 	c.JSON(http.StatusCreated, score)
 }
@@ -144,22 +306,28 @@ func CreateScore(c *gin.Context) {
 // GET
 
 func GetAllCompetitions(c *gin.Context) {
-	query := "SELECT * FROM competitions"
-	rows, err := config.DB.Query(query)
+	// Private competitions are listed for organisers and API keys, the same
+	// callers requireCompetitionAccess serves their resources to.
+	includePrivate := bearerToken(c) != "" && c.Query("include_private") == "true"
+	// API keys restricted to a competition only see that one.
+	keyCompetition := c.GetInt(APIKeyCompetitionKey)
+	query := "SELECT competition_id, competition_name, " + utils.CompetitionSettingsColumns + ` FROM competitions
+		WHERE organisation_id = $1 AND ($2 OR visibility = 'public') AND ($3 = 0 OR competition_id = $3)`
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, organisationID(c), includePrivate, keyCompetition)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competitions", "error": err.Error()})
 		return
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("Error closing competition rows: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Error closing competition rows", "error", err)
 		}
 	}()
 
 	var competitions []types.Competition
 	for rows.Next() {
 		var competition types.Competition
-		if err := rows.Scan(&competition.ID, &competition.Name); err != nil {
+		if err := utils.ScanCompetitionSettings(rows, &competition.Settings, &competition.ID, &competition.Name); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan competition rows", "error": err.Error()})
 			return
 		}
@@ -174,15 +342,15 @@ func GetAllCompetitions(c *gin.Context) {
 }
 
 func GetAllCategories(c *gin.Context) {
-	query := "SELECT * FROM competition_categories"
-	rows, err := config.DB.Query(query)
+	query := "SELECT category_id, name FROM competition_categories WHERE organisation_id = $1"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, organisationID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competition categories", "error": err.Error()})
 		return
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("Error closing competition category rows: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Error closing competition category rows", "error", err)
 		}
 	}()
 
@@ -204,24 +372,30 @@ func GetAllCategories(c *gin.Context) {
 }
 
 func GetBoulderProblems(c *gin.Context) {
-	round := c.Param("round")
-	query := "SELECT problem_id, problem_number FROM boulder_problems WHERE round_id = $1"
+	round := c.Param("id")
+	if !requireOwnership(c, utils.ResourceRound, round) {
+		return
+	}
 
-	rows, err := config.DB.Query(query, round)
+	query := `SELECT problem_id, problem_number, round_id, grade, colour, sector, setter, photo_url, has_zone,
+			top_points, zone_points, flash_bonus, attempt_penalty
+		FROM boulder_problems WHERE round_id = $1 ORDER BY problem_number`
+
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, round)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get boulder problems", "error": err.Error()})
 		return
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("Error closing boulder problem rows: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Error closing boulder problem rows", "error", err)
 		}
 	}()
 
 	var boulderProblems []types.BoulderProblem
 	for rows.Next() {
 		var boulderProblem types.BoulderProblem
-		if err := rows.Scan(&boulderProblem.ID, &boulderProblem.Number); err != nil {
+		if err := scanBoulderProblem(rows, &boulderProblem); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan boulder problem rows", "error": err.Error()})
 			return
 		}
@@ -235,24 +409,34 @@ func GetBoulderProblems(c *gin.Context) {
 	c.JSON(http.StatusOK, boulderProblems)
 }
 
+func scanBoulderProblem(row interface{ Scan(...interface{}) error }, boulderProblem *types.BoulderProblem) error {
+	return row.Scan(&boulderProblem.ID, &boulderProblem.Number, &boulderProblem.RoundID, &boulderProblem.Grade, &boulderProblem.Colour,
+		&boulderProblem.Sector, &boulderProblem.Setter, &boulderProblem.PhotoURL, &boulderProblem.HasZone, &boulderProblem.Points.Top,
+		&boulderProblem.Points.Zone, &boulderProblem.Points.FlashBonus, &boulderProblem.Points.AttemptPenalty)
+}
+
 func GetAllRounds(c *gin.Context) {
-	competition := c.Param("competition")
-	query := "SELECT round_id, round_number, start_date, end_date FROM rounds WHERE competition_id = $1"
-	rows, err := config.DB.Query(query, competition)
+	competition := c.Param("id")
+	if !requireOwnership(c, utils.ResourceCompetition, competition) {
+		return
+	}
+
+	query := "SELECT round_id, round_number, start_date, end_date, stage FROM rounds WHERE competition_id = $1"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, competition)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get rounds", "error": err.Error()})
 		return
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("Error closing rounds rows: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Error closing rounds rows", "error", err)
 		}
 	}()
 
 	var rounds []types.Round
 	for rows.Next() {
 		var round types.Round
-		if err := rows.Scan(&round.ID, &round.Number, &round.StartDate, &round.EndDate); err != nil {
+		if err := rows.Scan(&round.ID, &round.Number, &round.StartDate, &round.EndDate, &round.Stage); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan rounds rows", "error": err.Error()})
 			return
 		}
@@ -267,15 +451,15 @@ func GetAllRounds(c *gin.Context) {
 }
 
 func GetAllCompetitors(c *gin.Context) {
-	query := "SELECT competitor_id, name, category_id FROM competitors"
-	rows, err := config.DB.Query(query)
+	query := "SELECT competitor_id, name, category_id FROM competitors WHERE organisation_id = $1"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, organisationID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competitors", "error": err.Error()})
 		return
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("Error closing competitor rows: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Error closing competitor rows", "error", err)
 		}
 	}()
 
@@ -297,55 +481,69 @@ func GetAllCompetitors(c *gin.Context) {
 }
 
 func GetAllScores(c *gin.Context) {
-	category := c.Query("category")
-	competition := c.Query("competition")
-	query, err := utils.BuildScoresQueryString(category, competition)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error building scores query string", "error": err.Error()})
+	options := types.LeaderboardOptions{
+		Competition:     c.Query("competition"),
+		Category:        c.Query("category"),
+		Stage:           c.Query("stage"),
+		HideNonStarters: c.Query("hide_non_starters") == "true",
+	}
+
+	if !requireOwnership(c, utils.ResourceCompetition, options.Competition) || !requireOwnership(c, utils.ResourceCategory, options.Category) {
 		return
 	}
 
-	rows, err := config.DB.Query(query)
+	totalScores, err := utils.GetLeaderboard(c.Request.Context(), options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get scores", "error": err.Error()})
 		return
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Printf("Error closing scores rows: %v", err)
-		}
-	}()
 
-	var totalScores []types.TotalScore
-	columns, err := rows.Columns()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error getting total scores columns", "error": err.Error()})
+	_, span := tracing.Tracer().Start(c.Request.Context(), "render leaderboard")
+	c.JSON(http.StatusOK, totalScores)
+	span.End()
+}
+
+// PATCH
+
+func UpdateBoulderProblem(c *gin.Context) {
+	var update types.BoulderProblemUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind boulder problem JSON", "error": err.Error()})
 		return
 	}
-	values := make([]interface{}, len(columns))
-	for rows.Next() {
-		for i := range values {
-			values[i] = new(interface{})
-		}
-
-		err := rows.Scan(values...)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan total scores row", "error": err.Error()})
-			return
-		}
 
-		totalScore := make(types.TotalScore)
-		for i, column := range columns {
-			val := *(values[i].(*interface{}))
-			totalScore[column] = val
-		}
-		totalScores = append(totalScores, totalScore)
+	if !requireOwnership(c, utils.ResourceBoulderProblem, c.Param("id")) {
+		return
 	}
 
-	if err := rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over total scores rows", "error": err.Error()})
+	query := `UPDATE boulder_problems SET
+			problem_number = COALESCE($2, problem_number),
+			grade = COALESCE($3, grade),
+			colour = COALESCE($4, colour),
+			sector = COALESCE($5, sector),
+			setter = COALESCE($6, setter),
+			photo_url = COALESCE($7, photo_url),
+			has_zone = COALESCE($8, has_zone),
+			top_points = COALESCE($9, top_points),
+			zone_points = COALESCE($10, zone_points),
+			flash_bonus = COALESCE($11, flash_bonus),
+			attempt_penalty = COALESCE($12, attempt_penalty)
+		WHERE problem_id = $1
+		RETURNING problem_id, problem_number, round_id, grade, colour, sector, setter, photo_url, has_zone,
+			top_points, zone_points, flash_bonus, attempt_penalty`
+
+	var boulderProblem types.BoulderProblem
+	row := config.DB.QueryRowContext(c.Request.Context(), query, c.Param("id"), update.Number, update.Grade, update.Colour, update.Sector, update.Setter,
+		update.PhotoURL, update.HasZone, update.Points.Top, update.Points.Zone, update.Points.FlashBonus, update.Points.AttemptPenalty)
+	err := scanBoulderProblem(row, &boulderProblem)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Boulder problem not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update boulder problem", "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, totalScores)
+	c.JSON(http.StatusOK, boulderProblem)
 }
@@ -3,51 +3,53 @@ package routes_test
 import (
 	"bytes"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/mail"
 	"github.com/josenymad/boulder-api/routes"
 	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
+// seedOrganiserToken is the token of the organiser in testdata/seed.sql.
+const seedOrganiserToken = "seed-organiser-token"
+
 func setUpRouter() *gin.Engine {
-	router := gin.Default()
-	router.POST("/competition", routes.CreateCompetition)
-	router.POST("/categories", routes.CreateCompetitionCategory)
-	router.POST("/rounds", routes.CreateRound)
-	router.POST("/competitors", routes.CreateCompetitor)
-	router.POST("/boulder-problems", routes.CreateBoulderProblem)
-	router.POST("/scores", routes.CreateScore)
-	return router
+	return routes.NewRouter(routes.Deps{Mailer: &mail.MemoryMailer{}, AdminToken: testAdminToken})
 }
 
 func TestCreateCompetition(t *testing.T) {
+	requireDB(t)
 	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
-	}
 
-	competition := types.Competition{
+	scoringMode := types.ScoringModeDynamic
+	countedRounds := 3
+	competition := types.NewCompetition{
 		Name: "Test Competition",
+		Settings: types.CompetitionSettingsUpdate{
+			ScoringMode:   &scoringMode,
+			CountedRounds: &countedRounds,
+		},
 	}
 	body, err := json.Marshal(competition)
 	if err != nil {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/competition", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/competition", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+seedOrganiserToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -59,14 +61,28 @@ func TestCreateCompetition(t *testing.T) {
 		t.Fatalf("Failed to unmarshal JSON: %v", err)
 	}
 	assert.Equal(t, "Test Competition", response["name"])
+	settings := response["settings"].(map[string]interface{})
+	assert.Equal(t, types.ScoringModeDynamic, settings["scoring_mode"])
+	assert.Equal(t, float64(3), settings["counted_rounds"])
+	assert.Equal(t, float64(1000), settings["points_pool"])
+}
+
+func TestCreateCompetitionInvalidSettings(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	organiser := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + seedOrganiserToken}}}
+
+	timezone := "Mars/Olympus_Mons"
+	w := organiser.do("POST", "/v1/competition", types.NewCompetition{
+		Name:     "Invalid Competition",
+		Settings: types.CompetitionSettingsUpdate{Timezone: &timezone},
+	}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
 func TestCreateCompetitionCategory(t *testing.T) {
+	requireDB(t)
 	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
-	}
 
 	category := types.Category{
 		Name: "Test Category",
@@ -76,11 +92,12 @@ func TestCreateCompetitionCategory(t *testing.T) {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/categories", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/categories", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+seedOrganiserToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -95,11 +112,8 @@ func TestCreateCompetitionCategory(t *testing.T) {
 }
 
 func TestCreateRound(t *testing.T) {
+	requireDB(t)
 	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
-	}
 
 	round := types.Round{
 		Number:        1,
@@ -112,11 +126,12 @@ func TestCreateRound(t *testing.T) {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/rounds", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/rounds", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+seedOrganiserToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -133,15 +148,14 @@ func TestCreateRound(t *testing.T) {
 }
 
 func TestCreateCompetitor(t *testing.T) {
-	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
-	}
+	requireDB(t)
+	mailer := &mail.MemoryMailer{}
+	router := routes.NewRouter(routes.Deps{Mailer: mailer, AdminToken: testAdminToken})
 
+	email := fmt.Sprintf("test+%d@mail.com", time.Now().UnixNano())
 	competitor := types.Competitor{
 		Name:       "Test Competitor",
-		Email:      "test@mail.com",
+		Email:      email,
 		Password:   "test_password",
 		CategoryID: 7,
 	}
@@ -150,11 +164,12 @@ func TestCreateCompetitor(t *testing.T) {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/competitors", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/competitors", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Organisation-ID", "1")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -166,29 +181,104 @@ func TestCreateCompetitor(t *testing.T) {
 	}
 	assert.Equal(t, "Test Competitor", response["name"])
 	assert.Equal(t, float64(7), response["category_id"])
+	if assert.Len(t, mailer.Messages(), 1) {
+		assert.Equal(t, email, mailer.Messages()[0].To)
+		assert.Equal(t, "Verify your email address", mailer.Messages()[0].Subject)
+	}
 }
 
-func TestCreateBloc(t *testing.T) {
+func TestCreateCompetitorDuplicateEmail(t *testing.T) {
+	requireDB(t)
 	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
+
+	email := fmt.Sprintf("duplicate+%d@mail.com", time.Now().UnixNano())
+	var responses []*httptest.ResponseRecorder
+	for _, registeredEmail := range []string{email, "  " + strings.ToUpper(email)} {
+		competitor := types.Competitor{
+			Name:       "Test Competitor",
+			Email:      registeredEmail,
+			Password:   "test_password",
+			CategoryID: 7,
+		}
+		body, err := json.Marshal(competitor)
+		if err != nil {
+			t.Fatalf("Failed to marshal JSON: %v", err)
+		}
+
+		req, err := http.NewRequest("POST", "/v1/competitors", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Organisation-ID", "1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		responses = append(responses, w)
+	}
+
+	assert.Equal(t, 201, responses[0].Code)
+	assert.Equal(t, 409, responses[1].Code)
+	var created, response map[string]interface{}
+	if err := json.Unmarshal(responses[0].Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if err := json.Unmarshal(responses[1].Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	assert.Equal(t, created["id"], response["competitor"].(map[string]interface{})["id"])
+}
+
+func TestCreateCompetitorRegistrationClosed(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	closed := map[string]interface{}{
+		"registration_opens_at":  time.Now().Add(-48 * time.Hour),
+		"registration_closes_at": time.Now().Add(-24 * time.Hour),
 	}
+	w := f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), closed, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	visitor := client{t: t, router: router, header: http.Header{"X-Organisation-ID": {fmt.Sprint(f.organisation.ID)}}}
+	competitor := types.Competitor{
+		Name:       "Late Competitor",
+		Email:      "late@mail.com",
+		Password:   "test_password",
+		CategoryID: f.category.ID,
+	}
+	w = visitor.do("POST", "/v1/competitors", competitor, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Clearing the window opens registration again.
+	w = f.organiser.do("PATCH", fmt.Sprintf("/v1/competitions/%d/settings", f.competition.ID), map[string]interface{}{"clear_registration_window": true}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	visitor.create("/v1/competitors", competitor, nil)
+}
+
+func TestCreateBloc(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
 
 	bloc := types.BoulderProblem{
 		Number:  2,
 		RoundID: 21,
+		Grade:   "6B+",
+		Colour:  "Yellow",
+		HasZone: true,
 	}
 	body, err := json.Marshal(bloc)
 	if err != nil {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/boulder-problems", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/boulder-problems", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+seedOrganiserToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -200,18 +290,18 @@ func TestCreateBloc(t *testing.T) {
 	}
 	assert.Equal(t, float64(2), response["number"])
 	assert.Equal(t, float64(21), response["round_id"])
+	assert.Equal(t, "6B+", response["grade"])
+	assert.Equal(t, "Yellow", response["colour"])
+	assert.Equal(t, true, response["has_zone"])
 }
 
 func TestCreateScore(t *testing.T) {
+	requireDB(t)
 	router := setUpRouter()
-	err := config.ConnectDB("test")
-	if err != nil {
-		log.Fatalf("Could not set up database: %v", err)
-	}
 
 	score := types.Score{
 		Attempts:     1,
-		Points:       1,
+		Topped:       true,
 		CompetitorID: 19,
 		ProblemID:    51,
 	}
@@ -220,11 +310,12 @@ func TestCreateScore(t *testing.T) {
 		t.Fatalf("Failed to marshal JSON: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "/scores", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", "/v1/scores", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+seedOrganiserToken)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -235,7 +326,113 @@ func TestCreateScore(t *testing.T) {
 		t.Fatalf("Failed to unmarshal JSON: %v", err)
 	}
 	assert.Equal(t, float64(1), response["attempts"])
-	assert.Equal(t, float64(1), response["points"])
+	assert.Equal(t, true, response["topped"])
+	assert.Greater(t, response["points"], float64(0))
 	assert.Equal(t, float64(19), response["competitor_id"])
 	assert.Equal(t, float64(51), response["problem_id"])
 }
+
+func TestCreateScoreOverride(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	f := newFixture(t, router)
+
+	var apiKey types.APIKey
+	f.organiser.create("/v1/api-keys", types.APIKey{Name: "Kiosk", Scope: types.ScopeScores}, &apiKey)
+	kiosk := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + apiKey.Key}}}
+
+	score := types.Score{
+		Attempts:     1,
+		Topped:       true,
+		Points:       500,
+		Override:     true,
+		CompetitorID: f.competitors["Dana"].ID,
+		ProblemID:    f.problems[0].ID,
+	}
+	w := kiosk.do("POST", "/v1/scores", score, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var created types.Score
+	f.organiser.create("/v1/scores", score, &created)
+	assert.Equal(t, 500, created.Points)
+}
+
+func TestCreateOrganiserInvitationUnknownOrganisation(t *testing.T) {
+	requireDB(t)
+	router := setUpRouter()
+	admin := client{t: t, router: router, header: http.Header{"Authorization": {"Bearer " + testAdminToken}}}
+
+	w := admin.do("POST", "/v1/admin/organisations/999999/invitations", types.OrganiserInvitation{Email: "organiser@mail.com"}, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = admin.do("POST", "/v1/admin/organisations/first/invitations", types.OrganiserInvitation{Email: "organiser@mail.com"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWritesNeedCredentials(t *testing.T) {
+	router := setUpRouter()
+
+	for _, route := range []struct {
+		method string
+		path   string
+	}{
+		{"POST", "/v1/competition"},
+		{"POST", "/v1/categories"},
+		{"POST", "/v1/rounds"},
+		{"POST", "/v1/boulder-problems"},
+		{"POST", "/v1/scores"},
+		{"POST", "/v1/competitions/15/stages"},
+		{"POST", "/v1/competitions/15/stages/qualification/advance"},
+		{"POST", "/v1/start-lists/21"},
+		{"PATCH", "/v1/boulder-problems/51"},
+		{"PATCH", "/v1/competitions/15/settings"},
+		{"POST", "/competition"},
+	} {
+		req, err := http.NewRequest(route.method, route.path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Organisation-ID", "1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s", route.method, route.path)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	router := setUpRouter()
+	limiter := utils.NewRateLimiter(types.RateLimit{PerMinute: 1, Burst: 1})
+	router.GET("/limited", routes.RateLimitMiddleware(limiter), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	var codes []int
+	for _, forwardedFor := range []string{"203.0.113.1", "203.0.113.2"} {
+		req, err := http.NewRequest("GET", "/limited", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.RemoteAddr = "198.51.100.7:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	assert.Equal(t, []int{http.StatusNoContent, http.StatusTooManyRequests}, codes)
+}
+
+func TestRenamedRoutesLinkToSuccessor(t *testing.T) {
+	router := setUpRouter()
+
+	for path, successor := range map[string]string{
+		"/rounds/15":           "</v1/competitions/15/rounds>; rel=\"successor-version\"",
+		"/boulder-problems/21": "</v1/rounds/21/boulder-problems>; rel=\"successor-version\"",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, successor, w.Header().Get("Link"), path)
+		assert.NotEmpty(t, w.Header().Get("Deprecation"), path)
+	}
+}
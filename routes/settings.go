@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+)
+
+// GET
+
+func GetCompetitionSettings(c *gin.Context) {
+	if !requireOwnership(c, utils.ResourceCompetition, c.Param("id")) {
+		return
+	}
+
+	settings, err := utils.GetCompetitionSettings(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Competition not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competition settings", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// PATCH
+
+func UpdateCompetitionSettings(c *gin.Context) {
+	var update types.CompetitionSettingsUpdate
+	if err := c.BindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind competition settings JSON", "error": err.Error()})
+		return
+	}
+
+	competition := c.Param("id")
+	if !requireOwnership(c, utils.ResourceCompetition, competition) {
+		return
+	}
+
+	settings, err := utils.GetCompetitionSettings(c.Request.Context(), competition)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Competition not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competition settings", "error": err.Error()})
+		return
+	}
+
+	utils.ApplySettingsUpdate(&settings, update)
+	if err := utils.ValidateSettings(settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid competition settings", "error": err.Error()})
+		return
+	}
+
+	if err := utils.SaveCompetitionSettings(c.Request.Context(), config.DB, competition, settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update competition settings", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
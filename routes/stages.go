@@ -0,0 +1,248 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+	"github.com/lib/pq"
+)
+
+// POST
+
+func CreateStage(c *gin.Context) {
+	var stage types.StageConfig
+	if err := c.BindJSON(&stage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind stage JSON", "error": err.Error()})
+		return
+	}
+
+	competitionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid competition ID", "error": err.Error()})
+		return
+	}
+	stage.CompetitionID = competitionID
+
+	if !requireOwnership(c, utils.ResourceCompetition, competitionID) {
+		return
+	}
+
+	query := `INSERT INTO stages (competition_id, stage, quota, points_rule) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (competition_id, stage) DO UPDATE SET quota = EXCLUDED.quota, points_rule = EXCLUDED.points_rule
+		RETURNING stage_id`
+
+	err = config.DB.QueryRowContext(c.Request.Context(), query, stage.CompetitionID, stage.Stage, stage.Quota, stage.PointsRule).Scan(&stage.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create stage", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stage)
+}
+
+// AdvanceStage fills the start list of the next configured stage with the top
+// competitors of each category in the given stage, replacing any start list
+// from an earlier run.
+func AdvanceStage(c *gin.Context) {
+	competition := c.Param("id")
+	stage := c.Param("stage")
+
+	if !requireOwnership(c, utils.ResourceCompetition, competition) {
+		return
+	}
+
+	laterStages, ok := utils.NextStages(stage)
+	if !ok || len(laterStages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid stage to advance from", "error": "stage must be qualification or semi_final"})
+		return
+	}
+
+	var nextStage types.StageConfig
+	query := `SELECT stage_id, competition_id, stage, quota, points_rule FROM stages
+		WHERE competition_id = $1 AND stage = ANY($2)
+		ORDER BY array_position($2, stage) LIMIT 1`
+	err := config.DB.QueryRowContext(c.Request.Context(), query, competition, pq.Array(laterStages)).Scan(
+		&nextStage.ID, &nextStage.CompetitionID, &nextStage.Stage, &nextStage.Quota, &nextStage.PointsRule)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "No stage configured after " + stage, "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get next stage", "error": err.Error()})
+		return
+	}
+
+	settings, err := utils.GetCompetitionSettings(c.Request.Context(), competition)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competition settings", "error": err.Error()})
+		return
+	}
+
+	categories, err := getCategoryIDs(c.Request.Context(), organisationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competition categories", "error": err.Error()})
+		return
+	}
+
+	// The leaderboards are read before the transaction starts, since they use
+	// connections of their own from the pool.
+	var qualifiers []types.StartListEntry
+	for _, category := range categories {
+		totalScores, err := utils.GetLeaderboard(c.Request.Context(), types.LeaderboardOptions{
+			Competition:     competition,
+			Category:        strconv.Itoa(category),
+			Stage:           stage,
+			HideNonStarters: true,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get leaderboard", "error": err.Error()})
+			return
+		}
+
+		for _, entry := range utils.SelectQualifiers(totalScores, nextStage.Quota, settings.TieBreak) {
+			entry.CompetitionID = nextStage.CompetitionID
+			entry.Stage = nextStage.Stage
+			entry.CategoryID = category
+			if nextStage.PointsRule == types.PointsRuleReset {
+				entry.CarriedPoints = 0
+			}
+			qualifiers = append(qualifiers, entry)
+		}
+	}
+
+	tx, err := config.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start transaction", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(c.Request.Context(), "Error rolling back stage advance", "error", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM start_list_entries WHERE competition_id = $1 AND stage = $2", competition, nextStage.Stage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to clear start list", "error": err.Error()})
+		return
+	}
+
+	startList := []types.StartListEntry{}
+	for _, entry := range qualifiers {
+		query := `INSERT INTO start_list_entries (competition_id, stage, category_id, competitor_id, position, carried_points)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING entry_id`
+		err := tx.QueryRowContext(c.Request.Context(), query, entry.CompetitionID, entry.Stage, entry.CategoryID, entry.CompetitorID, entry.Position, entry.CarriedPoints).Scan(&entry.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create start list entry", "error": err.Error()})
+			return
+		}
+		startList = append(startList, entry)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit start list", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, startList)
+}
+
+// GET
+
+func GetStages(c *gin.Context) {
+	competition := c.Param("id")
+	if !requireOwnership(c, utils.ResourceCompetition, competition) {
+		return
+	}
+
+	query := "SELECT stage_id, competition_id, stage, quota, points_rule FROM stages WHERE competition_id = $1"
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, competition)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get stages", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error closing stage rows", "error", err)
+		}
+	}()
+
+	var stages []types.StageConfig
+	for rows.Next() {
+		var stage types.StageConfig
+		if err := rows.Scan(&stage.ID, &stage.CompetitionID, &stage.Stage, &stage.Quota, &stage.PointsRule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan stage rows", "error": err.Error()})
+			return
+		}
+		stages = append(stages, stage)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over stage rows", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stages)
+}
+
+func GetStageStartList(c *gin.Context) {
+	competition := c.Param("id")
+	stage := c.Param("stage")
+	if !requireOwnership(c, utils.ResourceCompetition, competition) {
+		return
+	}
+
+	query := `SELECT entry_id, competition_id, stage, category_id, competitor_id, position, carried_points
+		FROM start_list_entries WHERE competition_id = $1 AND stage = $2 ORDER BY category_id, position`
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, competition, stage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get start list", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error closing start list rows", "error", err)
+		}
+	}()
+
+	var startList []types.StartListEntry
+	for rows.Next() {
+		var entry types.StartListEntry
+		if err := rows.Scan(&entry.ID, &entry.CompetitionID, &entry.Stage, &entry.CategoryID, &entry.CompetitorID, &entry.Position, &entry.CarriedPoints); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan start list rows", "error": err.Error()})
+			return
+		}
+		startList = append(startList, entry)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over start list rows", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, startList)
+}
+
+func getCategoryIDs(ctx context.Context, organisation int) ([]int, error) {
+	rows, err := config.DB.QueryContext(ctx, "SELECT category_id FROM competition_categories WHERE organisation_id = $1 ORDER BY category_id", organisation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []int
+	for rows.Next() {
+		var category int
+		if err := rows.Scan(&category); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
@@ -0,0 +1,257 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+)
+
+// POST
+
+// GenerateStartList draws the running order and time slots for one category in
+// a round, replacing any earlier running order. Qualification rounds are
+// ranked on the qualification leaderboard; later stages use the positions on
+// the stage's start list.
+func GenerateStartList(c *gin.Context) {
+	var request types.StartListRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to bind start list JSON", "error": err.Error()})
+		return
+	}
+
+	roundID, err := strconv.Atoi(c.Param("round"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid round ID", "error": err.Error()})
+		return
+	}
+
+	if !requireOwnership(c, utils.ResourceRound, roundID) || !requireOwnership(c, utils.ResourceCategory, request.CategoryID) {
+		return
+	}
+
+	var competition, stage string
+	query := "SELECT competition_id, stage FROM rounds WHERE round_id = $1"
+	err = config.DB.QueryRowContext(c.Request.Context(), query, roundID).Scan(&competition, &stage)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Round not found", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get round", "error": err.Error()})
+		return
+	}
+
+	var entries []types.RunningOrderEntry
+	if stage == types.StageQualification {
+		entries, err = getRankedCompetitors(c.Request.Context(), competition, request.CategoryID)
+	} else {
+		entries, err = getStageCompetitors(c.Request.Context(), competition, stage, request.CategoryID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get competitors for start list", "error": err.Error()})
+		return
+	}
+
+	utils.OrderStartList(entries, request.Order, request.Seed)
+	utils.AssignTimeSlots(entries, request.StartTime, time.Duration(request.SlotMinutes)*time.Minute, time.Duration(request.IsolationMinutes)*time.Minute)
+
+	tx, err := config.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to start transaction", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(c.Request.Context(), "Error rolling back start list", "error", err)
+		}
+	}()
+
+	_, err = tx.ExecContext(c.Request.Context(), "DELETE FROM running_orders WHERE round_id = $1 AND category_id = $2", roundID, request.CategoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to clear running order", "error": err.Error()})
+		return
+	}
+
+	for index := range entries {
+		entry := &entries[index]
+		entry.RoundID = roundID
+		entry.CategoryID = request.CategoryID
+
+		query := `INSERT INTO running_orders (round_id, category_id, competitor_id, running_position, ranking, isolation_time, slot_start, slot_end)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING running_order_id`
+		err := tx.QueryRowContext(c.Request.Context(), query, entry.RoundID, entry.CategoryID, entry.CompetitorID, entry.Position, entry.Rank, entry.IsolationTime, entry.SlotStart, entry.SlotEnd).Scan(&entry.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create running order entry", "error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to commit running order", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entries)
+}
+
+// GET
+
+// GetStartList returns a round's running order as JSON, or as CSV when the
+// format query parameter is csv or the client only accepts text/csv.
+func GetStartList(c *gin.Context) {
+	round := c.Param("round")
+	category := c.Query("category")
+	if !requireOwnership(c, utils.ResourceRound, round) {
+		return
+	}
+
+	query := `SELECT ro.running_order_id, ro.round_id, ro.category_id, ro.competitor_id, c.name, ro.running_position,
+			ro.ranking, ro.isolation_time, ro.slot_start, ro.slot_end
+		FROM running_orders ro
+		INNER JOIN competitors c ON ro.competitor_id = c.competitor_id
+		WHERE ro.round_id = $1 AND ($2 = '' OR ro.category_id::TEXT = $2)
+		ORDER BY ro.category_id, ro.running_position`
+	rows, err := config.DB.QueryContext(c.Request.Context(), query, round, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get start list", "error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error closing running order rows", "error", err)
+		}
+	}()
+
+	var entries []types.RunningOrderEntry
+	for rows.Next() {
+		var entry types.RunningOrderEntry
+		if err := rows.Scan(&entry.ID, &entry.RoundID, &entry.CategoryID, &entry.CompetitorID, &entry.CompetitorName, &entry.Position,
+			&entry.Rank, &entry.IsolationTime, &entry.SlotStart, &entry.SlotEnd); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to scan running order rows", "error": err.Error()})
+			return
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error iterating over running order rows", "error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" || c.NegotiateFormat(gin.MIMEJSON, mimeCSV) == mimeCSV {
+		writeStartListCSV(c, round, entries)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+const mimeCSV = "text/csv"
+
+func writeStartListCSV(c *gin.Context, round string, entries []types.RunningOrderEntry) {
+	c.Header("Content-Type", mimeCSV)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=round-%s-start-list.csv", round))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	records := [][]string{{"position", "competitor_id", "competitor_name", "category_id", "rank", "isolation_time", "slot_start", "slot_end"}}
+	for _, entry := range entries {
+		rank := ""
+		if entry.Rank != nil {
+			rank = strconv.Itoa(*entry.Rank)
+		}
+		records = append(records, []string{
+			strconv.Itoa(entry.Position),
+			strconv.Itoa(entry.CompetitorID),
+			entry.CompetitorName,
+			strconv.Itoa(entry.CategoryID),
+			rank,
+			entry.IsolationTime.Format(time.RFC3339),
+			entry.SlotStart.Format(time.RFC3339),
+			entry.SlotEnd.Format(time.RFC3339),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error writing start list CSV", "error", err)
+	}
+}
+
+// getRankedCompetitors returns every competitor in a category, ranked on the
+// competition's qualification leaderboard. Competitors without scores are
+// left unranked.
+func getRankedCompetitors(ctx context.Context, competition string, category int) ([]types.RunningOrderEntry, error) {
+	totalScores, err := utils.GetLeaderboard(ctx, types.LeaderboardOptions{
+		Competition:     competition,
+		Category:        strconv.Itoa(category),
+		Stage:           types.StageQualification,
+		HideNonStarters: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := utils.GetCompetitionSettings(ctx, competition)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[int]int)
+	for _, ranked := range utils.SelectQualifiers(totalScores, len(totalScores), settings.TieBreak) {
+		ranks[ranked.CompetitorID] = ranked.Position
+	}
+
+	rows, err := config.DB.QueryContext(ctx, "SELECT competitor_id, name FROM competitors WHERE category_id = $1", category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.RunningOrderEntry
+	for rows.Next() {
+		var entry types.RunningOrderEntry
+		if err := rows.Scan(&entry.CompetitorID, &entry.CompetitorName); err != nil {
+			return nil, err
+		}
+		if rank, ok := ranks[entry.CompetitorID]; ok {
+			entry.Rank = &rank
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// getStageCompetitors returns the competitors who advanced into a stage in a
+// category, ranked by their position on the stage's start list.
+func getStageCompetitors(ctx context.Context, competition string, stage string, category int) ([]types.RunningOrderEntry, error) {
+	query := `SELECT sle.competitor_id, c.name, sle.position
+		FROM start_list_entries sle
+		INNER JOIN competitors c ON sle.competitor_id = c.competitor_id
+		WHERE sle.competition_id = $1 AND sle.stage = $2 AND sle.category_id = $3`
+	rows, err := config.DB.QueryContext(ctx, query, competition, stage, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.RunningOrderEntry
+	for rows.Next() {
+		var entry types.RunningOrderEntry
+		var rank int
+		if err := rows.Scan(&entry.CompetitorID, &entry.CompetitorName, &rank); err != nil {
+			return nil, err
+		}
+		entry.Rank = &rank
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+)
+
+// sendCondition is the SQL condition for a score that topped its problem.
+const sendCondition = "s.topped"
+
+// GET
+
+func GetBoulderProblemStats(c *gin.Context) {
+	problemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid boulder problem ID", "error": err.Error()})
+		return
+	}
+
+	if !requireOwnership(c, utils.ResourceBoulderProblem, problemID) {
+		return
+	}
+
+	problemStats, err := getProblemStats(c.Request.Context(), "bp.problem_id = $1", problemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get boulder problem stats", "error": err.Error()})
+		return
+	}
+	if len(problemStats) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Boulder problem not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, problemStats[0])
+}
+
+func GetRoundStats(c *gin.Context) {
+	roundID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid round ID", "error": err.Error()})
+		return
+	}
+
+	if !requireOwnership(c, utils.ResourceRound, roundID) {
+		return
+	}
+
+	problemStats, err := getProblemStats(c.Request.Context(), "bp.round_id = $1", roundID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get round stats", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.RoundStats{RoundID: roundID, Problems: problemStats})
+}
+
+// getProblemStats computes statistics for the boulder problems matching where,
+// a condition on boulder_problems bp that takes arg as $1.
+func getProblemStats(ctx context.Context, where string, arg interface{}) ([]types.ProblemStats, error) {
+	query := `SELECT bp.problem_id, bp.problem_number, bp.round_id,
+			COUNT(s.score_id),
+			COUNT(s.score_id) FILTER (WHERE ` + sendCondition + `),
+			COUNT(s.score_id) FILTER (WHERE ` + sendCondition + ` AND s.attempts = 1),
+			COALESCE(AVG(s.attempts), 0)
+		FROM boulder_problems bp
+		LEFT JOIN scores s ON bp.problem_id = s.problem_id
+		WHERE ` + where + `
+		GROUP BY bp.problem_id, bp.problem_number, bp.round_id
+		ORDER BY bp.problem_number`
+	rows, err := config.DB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.ErrorContext(ctx, "Error closing problem stats rows", "error", err)
+		}
+	}()
+
+	problemStats := []types.ProblemStats{}
+	indexes := make(map[int]int)
+	for rows.Next() {
+		var stats types.ProblemStats
+		if err := rows.Scan(&stats.ProblemID, &stats.Number, &stats.RoundID, &stats.Attempted, &stats.Sends, &stats.Flashes, &stats.AverageAttempts); err != nil {
+			return nil, err
+		}
+		stats.SendRate = rate(stats.Sends, stats.Attempted)
+		stats.FlashRate = rate(stats.Flashes, stats.Attempted)
+		stats.Categories = []types.CategoryStats{}
+		indexes[stats.ProblemID] = len(problemStats)
+		problemStats = append(problemStats, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT bp.problem_id, cat.category_id, cat.name,
+			COUNT(s.score_id),
+			COUNT(s.score_id) FILTER (WHERE ` + sendCondition + `),
+			COUNT(s.score_id) FILTER (WHERE ` + sendCondition + ` AND s.attempts = 1)
+		FROM boulder_problems bp
+		INNER JOIN scores s ON bp.problem_id = s.problem_id
+		INNER JOIN competitors c ON s.competitor_id = c.competitor_id
+		INNER JOIN competition_categories cat ON c.category_id = cat.category_id
+		WHERE ` + where + `
+		GROUP BY bp.problem_id, cat.category_id, cat.name
+		ORDER BY cat.category_id`
+	categoryRows, err := config.DB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := categoryRows.Close(); err != nil {
+			slog.ErrorContext(ctx, "Error closing category stats rows", "error", err)
+		}
+	}()
+
+	for categoryRows.Next() {
+		var problemID int
+		var stats types.CategoryStats
+		if err := categoryRows.Scan(&problemID, &stats.CategoryID, &stats.Name, &stats.Attempted, &stats.Sends, &stats.Flashes); err != nil {
+			return nil, err
+		}
+		index := indexes[problemID]
+		problemStats[index].Categories = append(problemStats[index].Categories, stats)
+	}
+
+	return problemStats, categoryRows.Err()
+}
+
+func rate(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
@@ -0,0 +1,21 @@
+-- Rows in the default organisation that the POST tests refer to by ID.
+INSERT INTO competitions (competition_id, competition_name, organisation_id) VALUES (15, 'Seed Competition', 1);
+INSERT INTO competition_categories (category_id, name, organisation_id) VALUES (7, 'Seed Category', 1);
+INSERT INTO rounds (round_id, round_number, start_date, end_date, competition_id)
+VALUES (21, 1, '2024-07-01 10:00:00+00', '2024-07-15 19:00:00+00', 15);
+INSERT INTO boulder_problems (problem_id, round_id, problem_number) VALUES (51, 21, 1);
+INSERT INTO competitors (competitor_id, name, email, password, category_id, organisation_id)
+VALUES (19, 'Seed Competitor', 'seed@mail.com', '', 7, 1);
+
+-- The POST tests write as this organiser, whose token is seedOrganiserToken.
+INSERT INTO organisers (organiser_id, organisation_id, name, email, token_hash)
+VALUES (3, 1, 'Seed Organiser', 'organiser@mail.com', encode(sha256('seed-organiser-token'), 'hex'));
+
+-- Move the sequences past the seeded IDs so rows the tests create don't
+-- collide with them.
+SELECT setval(pg_get_serial_sequence('competitions', 'competition_id'), 100);
+SELECT setval(pg_get_serial_sequence('competition_categories', 'category_id'), 100);
+SELECT setval(pg_get_serial_sequence('rounds', 'round_id'), 100);
+SELECT setval(pg_get_serial_sequence('boulder_problems', 'problem_id'), 100);
+SELECT setval(pg_get_serial_sequence('competitors', 'competitor_id'), 100);
+SELECT setval(pg_get_serial_sequence('organisers', 'organiser_id'), 100);
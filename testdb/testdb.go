@@ -0,0 +1,132 @@
+// Package testdb gives a test package a Postgres schema of its own, with the
+// migrations applied, so integration tests start from a known state and leave
+// nothing behind.
+package testdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/migrations"
+	"github.com/josenymad/boulder-api/utils"
+	"github.com/lib/pq"
+)
+
+// ErrNotConfigured is returned by Setup when there is no test database to
+// use, so callers can skip their database tests instead of failing.
+var ErrNotConfigured = errors.New("no test database configured: set TEST_DATABASE_URL or CONFIG_FILE")
+
+// Required reports whether a missing test database should fail the tests
+// rather than skip them: when CI or REQUIRE_TEST_DATABASE is set, so a
+// misconfigured build can't pass without running the database tests.
+func Required() bool {
+	return os.Getenv("CI") != "" || os.Getenv("REQUIRE_TEST_DATABASE") != ""
+}
+
+// Setup creates a schema with a random name in the test database, applies the
+// migrations and then the seed files to it, and points config.DB at it. The
+// test database is TEST_DATABASE_URL if it is set, otherwise the one in the
+// env file named by CONFIG_FILE. Call the returned function once the tests
+// have run to drop the schema and close the connection.
+func Setup(seeds ...string) (cleanup func() error, err error) {
+	database, err := testDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	connectionString := database.ConnectionString()
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		connectionString, err = pq.ParseURL(connectionString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TEST_DATABASE_URL: %w", err)
+		}
+	}
+
+	token, _, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	schema := "test_" + token[:16]
+
+	database.DSN = connectionString
+	if err := config.ConnectDB(database); err != nil {
+		return nil, err
+	}
+	_, err = config.DB.Exec("CREATE SCHEMA " + schema)
+	config.DB.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+
+	// Every connection in the pool starts out in the new schema.
+	database.DSN = connectionString + " search_path=" + schema
+	if err := config.ConnectDB(database); err != nil {
+		return nil, err
+	}
+
+	cleanup = func() error {
+		defer config.DB.Close()
+		if _, err := config.DB.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			return fmt.Errorf("failed to drop schema %s: %w", schema, err)
+		}
+		return nil
+	}
+
+	if err := migrate(context.Background(), seeds); err != nil {
+		return nil, errors.Join(err, cleanup())
+	}
+	return cleanup, nil
+}
+
+func testDatabase() (config.DatabaseConfig, error) {
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		database := config.Default().Database
+		database.DSN = url
+		return database, nil
+	}
+
+	// Without CONFIG_FILE, Load would fall back to a .env in the test's
+	// package directory, which is never the one meant.
+	if os.Getenv("CONFIG_FILE") == "" {
+		return config.DatabaseConfig{}, ErrNotConfigured
+	}
+	// The test config only needs to say where the database is.
+	cfg, err := config.Load([]string{"-development", "true"})
+	if err != nil {
+		return config.DatabaseConfig{}, fmt.Errorf("could not load test config: %w", err)
+	}
+	return cfg.Database, nil
+}
+
+// migrate runs the migrations in order, then the seed files.
+func migrate(ctx context.Context, seeds []string) error {
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		migration, err := fs.ReadFile(migrations.Files, name)
+		if err != nil {
+			return err
+		}
+		if _, err := config.DB.ExecContext(ctx, string(migration)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+	}
+
+	for _, name := range seeds {
+		seed, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if _, err := config.DB.ExecContext(ctx, string(seed)); err != nil {
+			return fmt.Errorf("failed to apply seed %s: %w", name, err)
+		}
+	}
+	return nil
+}
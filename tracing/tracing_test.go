@@ -0,0 +1,18 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josenymad/boulder-api/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetup(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.ExporterNone, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = tracing.Setup(context.Background(), "carrier-pigeon", 1)
+	assert.ErrorContains(t, err, "carrier-pigeon")
+}
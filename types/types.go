@@ -2,12 +2,11 @@ package types
 
 import "time"
 
-type EnvVars struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
+// RateLimit allows Burst requests at once, refilling at PerMinute requests a
+// minute.
+type RateLimit struct {
+	PerMinute float64
+	Burst     int
 }
 
 type Category struct {
@@ -15,12 +14,46 @@ type Category struct {
 	Name string `json:"name" binding:"required"`
 }
 
+const (
+	StageQualification = "qualification"
+	StageSemiFinal     = "semi_final"
+	StageFinal         = "final"
+)
+
+// Stages lists competition stages in the order competitors progress through
+// them.
+var Stages = []string{StageQualification, StageSemiFinal, StageFinal}
+
+const (
+	PointsRuleCarryOver = "carry_over"
+	PointsRuleReset     = "reset"
+)
+
 type Round struct {
 	ID            int       `json:"id"`
 	Number        int       `json:"number" binding:"required"`
 	StartDate     time.Time `json:"start_date" binding:"required"`
 	EndDate       time.Time `json:"end_date" binding:"required"`
 	CompetitionID int       `json:"competition_id" binding:"required"`
+	Stage         string    `json:"stage" binding:"omitempty,oneof=qualification semi_final final"`
+}
+
+type StageConfig struct {
+	ID            int    `json:"id"`
+	CompetitionID int    `json:"competition_id"`
+	Stage         string `json:"stage" binding:"required,oneof=semi_final final"`
+	Quota         int    `json:"quota" binding:"required,min=1"`
+	PointsRule    string `json:"points_rule" binding:"required,oneof=carry_over reset"`
+}
+
+type StartListEntry struct {
+	ID            int    `json:"id"`
+	CompetitionID int    `json:"competition_id"`
+	Stage         string `json:"stage"`
+	CategoryID    int    `json:"category_id"`
+	CompetitorID  int    `json:"competitor_id"`
+	Position      int    `json:"position"`
+	CarriedPoints int64  `json:"carried_points"`
 }
 
 type Competitor struct {
@@ -31,6 +64,19 @@ type Competitor struct {
 	CategoryID int    `json:"category_id" binding:"required"`
 }
 
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type PasswordReset struct {
+	Password string `json:"password" binding:"required,min=8"`
+}
+
 type CompetitorResponse struct {
 	ID         int    `json:"id"`
 	Name       string `json:"name" binding:"required"`
@@ -38,22 +84,276 @@ type CompetitorResponse struct {
 }
 
 type BoulderProblem struct {
-	ID      int `json:"id"`
-	Number  int `json:"number" binding:"required"`
-	RoundID int `json:"round_id" binding:"required"`
+	ID       int           `json:"id"`
+	Number   int           `json:"number" binding:"required"`
+	RoundID  int           `json:"round_id" binding:"required"`
+	Grade    string        `json:"grade"`
+	Colour   string        `json:"colour"`
+	Sector   string        `json:"sector"`
+	Setter   string        `json:"setter"`
+	PhotoURL string        `json:"photo_url" binding:"omitempty,url"`
+	HasZone  bool          `json:"has_zone"`
+	Points   ProblemPoints `json:"points"`
+}
+
+// ProblemPoints configures how the scoring engine scores a boulder problem.
+// Nil values fall back to the competition's defaults.
+type ProblemPoints struct {
+	Top            *int `json:"top" binding:"omitempty,min=0"`
+	Zone           *int `json:"zone" binding:"omitempty,min=0"`
+	FlashBonus     *int `json:"flash_bonus" binding:"omitempty,min=0"`
+	AttemptPenalty *int `json:"attempt_penalty" binding:"omitempty,min=0"`
+}
+
+// BoulderProblemUpdate holds the fields of a boulder problem to change. Nil
+// fields are left as they are.
+type BoulderProblemUpdate struct {
+	Number   *int          `json:"number" binding:"omitempty,min=1"`
+	Grade    *string       `json:"grade"`
+	Colour   *string       `json:"colour"`
+	Sector   *string       `json:"sector"`
+	Setter   *string       `json:"setter"`
+	PhotoURL *string       `json:"photo_url" binding:"omitempty,url"`
+	HasZone  *bool         `json:"has_zone"`
+	Points   ProblemPoints `json:"points"`
 }
 
+// Score is a competitor's result on a boulder problem. Points are calculated
+// by the server from Attempts, Topped and Zone; a client may only send them
+// with Override set.
 type Score struct {
-	ID           int `json:"id"`
-	Attempts     int `json:"attempts" binding:"required"`
-	Points       int `json:"points" binding:"required"`
-	CompetitorID int `json:"competitor_id" binding:"required"`
-	ProblemID    int `json:"problem_id" binding:"required"`
+	ID           int  `json:"id"`
+	Attempts     int  `json:"attempts" binding:"required,min=1"`
+	Topped       bool `json:"topped"`
+	Zone         bool `json:"zone"`
+	Points       int  `json:"points" binding:"min=0"`
+	Override     bool `json:"override"`
+	CompetitorID int  `json:"competitor_id" binding:"required"`
+	ProblemID    int  `json:"problem_id" binding:"required"`
 }
 
+// PointsConfig is a boulder problem's points configuration with the
+// competition's defaults filled in, along with the competition's attempt limit.
+// MaxAttempts is 0 when there is no limit.
+type PointsConfig struct {
+	Top            int
+	Zone           int
+	FlashBonus     int
+	AttemptPenalty int
+	HasZone        bool
+	MaxAttempts    int
+}
+
+const (
+	ScoringModeFixed   = "fixed"
+	ScoringModeDynamic = "dynamic"
+)
+
+const (
+	TieBreakNone           = "none"
+	TieBreakMostTops       = "most_tops"
+	TieBreakFewestAttempts = "fewest_attempts"
+)
+
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
 type Competition struct {
+	ID       int                 `json:"id"`
+	Name     string              `json:"name" binding:"required"`
+	Settings CompetitionSettings `json:"settings"`
+}
+
+// NewCompetition is the body for creating a competition. Settings that are
+// left out keep their defaults.
+type NewCompetition struct {
+	Name     string                    `json:"name" binding:"required"`
+	Settings CompetitionSettingsUpdate `json:"settings"`
+}
+
+// RegistrationWindow is when a competition takes registrations. A nil
+// OpensAt or ClosesAt leaves that end of the window open.
+type RegistrationWindow struct {
+	OpensAt  *time.Time
+	ClosesAt *time.Time
+	Timezone string
+}
+
+// CompetitionSettings controls how a competition is scored and validated.
+// CountedRounds and MaxAttempts are nil when there is no limit.
+type CompetitionSettings struct {
+	ScoringMode          string     `json:"scoring_mode"`
+	PointsPool           int        `json:"points_pool"`
+	TopPoints            int        `json:"top_points"`
+	ZonePoints           int        `json:"zone_points"`
+	FlashBonus           int        `json:"flash_bonus"`
+	AttemptPenalty       int        `json:"attempt_penalty"`
+	TieBreak             string     `json:"tie_break"`
+	CountedRounds        *int       `json:"counted_rounds"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	Venue                string     `json:"venue"`
+	Timezone             string     `json:"timezone"`
+	Visibility           string     `json:"visibility"`
+	MaxAttempts          *int       `json:"max_attempts"`
+}
+
+// CompetitionSettingsUpdate holds the settings to change. Nil fields are left
+// as they are, and a CountedRounds or MaxAttempts of 0 removes the limit.
+// ClearRegistrationWindow removes both registration times before any given in
+// the same update are applied.
+type CompetitionSettingsUpdate struct {
+	ScoringMode             *string    `json:"scoring_mode" binding:"omitempty,oneof=fixed dynamic"`
+	PointsPool              *int       `json:"points_pool" binding:"omitempty,min=1"`
+	TopPoints               *int       `json:"top_points" binding:"omitempty,min=0"`
+	ZonePoints              *int       `json:"zone_points" binding:"omitempty,min=0"`
+	FlashBonus              *int       `json:"flash_bonus" binding:"omitempty,min=0"`
+	AttemptPenalty          *int       `json:"attempt_penalty" binding:"omitempty,min=0"`
+	TieBreak                *string    `json:"tie_break" binding:"omitempty,oneof=none most_tops fewest_attempts"`
+	CountedRounds           *int       `json:"counted_rounds" binding:"omitempty,min=0"`
+	RegistrationOpensAt     *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt    *time.Time `json:"registration_closes_at"`
+	ClearRegistrationWindow bool       `json:"clear_registration_window"`
+	Venue                   *string    `json:"venue"`
+	Timezone                *string    `json:"timezone"`
+	Visibility              *string    `json:"visibility" binding:"omitempty,oneof=public private"`
+	MaxAttempts             *int       `json:"max_attempts" binding:"omitempty,min=0"`
+}
+
+type RoundStatus struct {
+	Number   int
+	Started  bool
+	Finished bool
+}
+
+const (
+	OrderReverseRank = "reverse_rank"
+	OrderRank        = "rank"
+	OrderRandom      = "random"
+)
+
+type StartListRequest struct {
+	CategoryID       int       `json:"category_id" binding:"required"`
+	Order            string    `json:"order" binding:"required,oneof=reverse_rank rank random"`
+	Seed             int64     `json:"seed"`
+	StartTime        time.Time `json:"start_time" binding:"required"`
+	SlotMinutes      int       `json:"slot_minutes" binding:"required,min=1"`
+	IsolationMinutes int       `json:"isolation_minutes" binding:"min=0"`
+}
+
+type RunningOrderEntry struct {
+	ID             int       `json:"id"`
+	RoundID        int       `json:"round_id"`
+	CategoryID     int       `json:"category_id"`
+	CompetitorID   int       `json:"competitor_id"`
+	CompetitorName string    `json:"competitor_name"`
+	Position       int       `json:"position"`
+	Rank           *int      `json:"rank"`
+	IsolationTime  time.Time `json:"isolation_time"`
+	SlotStart      time.Time `json:"slot_start"`
+	SlotEnd        time.Time `json:"slot_end"`
+}
+
+type TotalScore map[string]interface{}
+
+type LeaderboardOptions struct {
+	Competition     string
+	Category        string
+	Stage           string
+	HideNonStarters bool
+}
+
+type ProblemStats struct {
+	ProblemID       int             `json:"problem_id"`
+	Number          int             `json:"number"`
+	RoundID         int             `json:"round_id"`
+	Attempted       int             `json:"attempted"`
+	Sends           int             `json:"sends"`
+	Flashes         int             `json:"flashes"`
+	SendRate        float64         `json:"send_rate"`
+	FlashRate       float64         `json:"flash_rate"`
+	AverageAttempts float64         `json:"average_attempts"`
+	Categories      []CategoryStats `json:"categories"`
+}
+
+type CategoryStats struct {
+	CategoryID int    `json:"category_id"`
+	Name       string `json:"name"`
+	Attempted  int    `json:"attempted"`
+	Sends      int    `json:"sends"`
+	Flashes    int    `json:"flashes"`
+}
+
+type RoundStats struct {
+	RoundID  int            `json:"round_id"`
+	Problems []ProblemStats `json:"problems"`
+}
+
+type Organisation struct {
 	ID   int    `json:"id"`
 	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
 }
 
-type TotalScore map[string]interface{}
+type OrganiserInvitation struct {
+	ID             int       `json:"id"`
+	OrganisationID int       `json:"organisation_id"`
+	Email          string    `json:"email" binding:"required,email"`
+	Token          string    `json:"token,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type InvitationAcceptance struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Organiser is returned when an invitation is accepted. Token is only ever
+// shown in that response.
+type Organiser struct {
+	ID             int    `json:"id"`
+	OrganisationID int    `json:"organisation_id"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Token          string `json:"token,omitempty"`
+}
+
+const (
+	ScopeRead   = "read"
+	ScopeScores = "scores"
+)
+
+// APIKey is a scoped key for a machine client. Read keys can make any GET
+// request; score keys can also submit scores. A key with a CompetitionID only
+// works for that competition's leaderboard and scores. Key is only shown when
+// the key is created.
+type APIKey struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name" binding:"required"`
+	Scope         string     `json:"scope" binding:"required,oneof=read scores"`
+	CompetitionID *int       `json:"competition_id"`
+	Prefix        string     `json:"prefix"`
+	Key           string     `json:"key,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+}
+
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// Readiness is the body of the health probes, with the result of each check
+// the readiness probe runs.
+type Readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Status  string `json:"status"`
+	Version *int   `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
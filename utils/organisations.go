@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/josenymad/boulder-api/config"
+)
+
+const (
+	ResourceCompetition    = "competition"
+	ResourceCategory       = "category"
+	ResourceCompetitor     = "competitor"
+	ResourceRound          = "round"
+	ResourceBoulderProblem = "boulder problem"
+)
+
+// ownershipQueries check whether a resource ($1) belongs to an organisation
+// ($2). Rounds and boulder problems belong to their competition's
+// organisation.
+var ownershipQueries = map[string]string{
+	ResourceCompetition: "SELECT EXISTS (SELECT 1 FROM competitions WHERE competition_id = $1 AND organisation_id = $2)",
+	ResourceCategory:    "SELECT EXISTS (SELECT 1 FROM competition_categories WHERE category_id = $1 AND organisation_id = $2)",
+	ResourceCompetitor:  "SELECT EXISTS (SELECT 1 FROM competitors WHERE competitor_id = $1 AND organisation_id = $2)",
+	ResourceRound: `SELECT EXISTS (
+		SELECT 1 FROM rounds r
+		INNER JOIN competitions comp ON r.competition_id = comp.competition_id
+		WHERE r.round_id = $1 AND comp.organisation_id = $2)`,
+	ResourceBoulderProblem: `SELECT EXISTS (
+		SELECT 1 FROM boulder_problems bp
+		INNER JOIN rounds r ON bp.round_id = r.round_id
+		INNER JOIN competitions comp ON r.competition_id = comp.competition_id
+		WHERE bp.problem_id = $1 AND comp.organisation_id = $2)`,
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func BelongsToOrganisation(ctx context.Context, resource string, id interface{}, organisation int) (bool, error) {
+	query, ok := ownershipQueries[resource]
+	if !ok {
+		return false, fmt.Errorf("unknown resource %q", resource)
+	}
+
+	var belongs bool
+	err := config.DB.QueryRowContext(ctx, query, id, organisation).Scan(&belongs)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s ownership: %v", resource, err)
+	}
+	return belongs, nil
+}
+
+// competitionQueries find the competition a resource ($1) is part of.
+var competitionQueries = map[string]string{
+	ResourceCompetition: "SELECT competition_id FROM competitions WHERE competition_id = $1",
+	ResourceRound:       "SELECT competition_id FROM rounds WHERE round_id = $1",
+	ResourceBoulderProblem: `SELECT r.competition_id FROM boulder_problems bp
+		INNER JOIN rounds r ON bp.round_id = r.round_id
+		WHERE bp.problem_id = $1`,
+}
+
+// GetResourceCompetition returns the competition a competition, round or
+// boulder problem is part of. ok is false for resources that aren't part of a
+// competition.
+func GetResourceCompetition(ctx context.Context, resource string, id interface{}) (competition int, ok bool, err error) {
+	query, ok := competitionQueries[resource]
+	if !ok {
+		return 0, false, nil
+	}
+	err = config.DB.QueryRowContext(ctx, query, id).Scan(&competition)
+	return competition, true, err
+}
+
+// GetCompetitionVisibility returns whether a competition is public or private.
+func GetCompetitionVisibility(ctx context.Context, competition int) (visibility string, err error) {
+	query := "SELECT visibility FROM competitions WHERE competition_id = $1"
+	err = config.DB.QueryRowContext(ctx, query, competition).Scan(&visibility)
+	return visibility, err
+}
+
+// GetProblemCompetition returns the competition a boulder problem is set in.
+func GetProblemCompetition(ctx context.Context, problem int) (competition int, err error) {
+	competition, _, err = GetResourceCompetition(ctx, ResourceBoulderProblem, problem)
+	return competition, err
+}
+
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/josenymad/boulder-api/types"
+)
+
+// RateLimiter is a token bucket limiter with one bucket per key. Each bucket
+// holds up to Burst tokens and refills at PerMinute tokens a minute.
+type RateLimiter struct {
+	limit     types.RateLimit
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewRateLimiter(limit types.RateLimit) *RateLimiter {
+	return &RateLimiter{limit: limit, buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token from key's bucket at now. When the bucket is empty it
+// returns false and how long until the next token is available.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.limit.PerMinute <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration((1 - b.tokens) / l.limit.PerMinute * float64(time.Minute))
+	return false, wait
+}
+
+func (l *RateLimiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.updated).Minutes()
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return math.Min(float64(l.limit.Burst), b.tokens+elapsed*l.limit.PerMinute)
+}
+
+// sweep drops full buckets at most once a minute, so keys that stop sending
+// requests don't stay in memory.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/types"
+)
+
+// CompetitionSettingsColumns lists the competitions columns read by
+// ScanCompetitionSettings, in order.
+const CompetitionSettingsColumns = `scoring_mode, points_pool, top_points, zone_points, flash_bonus, attempt_penalty,
+	tie_break, counted_rounds, registration_opens_at, registration_closes_at, venue, timezone, visibility, max_attempts`
+
+func ScanCompetitionSettings(row interface{ Scan(...interface{}) error }, settings *types.CompetitionSettings, dest ...interface{}) error {
+	dest = append(dest, &settings.ScoringMode, &settings.PointsPool, &settings.TopPoints, &settings.ZonePoints,
+		&settings.FlashBonus, &settings.AttemptPenalty, &settings.TieBreak, &settings.CountedRounds,
+		&settings.RegistrationOpensAt, &settings.RegistrationClosesAt, &settings.Venue, &settings.Timezone,
+		&settings.Visibility, &settings.MaxAttempts)
+	return row.Scan(dest...)
+}
+
+// GetCompetitionSettings returns a competition's settings. The error wraps
+// sql.ErrNoRows when the competition doesn't exist.
+func GetCompetitionSettings(ctx context.Context, competition string) (types.CompetitionSettings, error) {
+	var settings types.CompetitionSettings
+	query := "SELECT " + CompetitionSettingsColumns + " FROM competitions WHERE competition_id = $1"
+	err := ScanCompetitionSettings(config.DB.QueryRowContext(ctx, query, competition), &settings)
+	if err != nil {
+		return settings, fmt.Errorf("failed to get competition settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Execer runs a statement. Both *sql.DB and *sql.Tx are Execers.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func SaveCompetitionSettings(ctx context.Context, db Execer, competition string, settings types.CompetitionSettings) error {
+	query := `UPDATE competitions SET
+			scoring_mode = $2, points_pool = $3, top_points = $4, zone_points = $5, flash_bonus = $6,
+			attempt_penalty = $7, tie_break = $8, counted_rounds = $9, registration_opens_at = $10,
+			registration_closes_at = $11, venue = $12, timezone = $13, visibility = $14, max_attempts = $15
+		WHERE competition_id = $1`
+	_, err := db.ExecContext(ctx, query, competition, settings.ScoringMode, settings.PointsPool, settings.TopPoints,
+		settings.ZonePoints, settings.FlashBonus, settings.AttemptPenalty, settings.TieBreak, settings.CountedRounds,
+		settings.RegistrationOpensAt, settings.RegistrationClosesAt, settings.Venue, settings.Timezone,
+		settings.Visibility, settings.MaxAttempts)
+	return err
+}
+
+// ApplySettingsUpdate copies the fields set in update onto settings.
+func ApplySettingsUpdate(settings *types.CompetitionSettings, update types.CompetitionSettingsUpdate) {
+	if update.ScoringMode != nil {
+		settings.ScoringMode = *update.ScoringMode
+	}
+	if update.PointsPool != nil {
+		settings.PointsPool = *update.PointsPool
+	}
+	if update.TopPoints != nil {
+		settings.TopPoints = *update.TopPoints
+	}
+	if update.ZonePoints != nil {
+		settings.ZonePoints = *update.ZonePoints
+	}
+	if update.FlashBonus != nil {
+		settings.FlashBonus = *update.FlashBonus
+	}
+	if update.AttemptPenalty != nil {
+		settings.AttemptPenalty = *update.AttemptPenalty
+	}
+	if update.TieBreak != nil {
+		settings.TieBreak = *update.TieBreak
+	}
+	if update.CountedRounds != nil {
+		settings.CountedRounds = update.CountedRounds
+		if *update.CountedRounds == 0 {
+			settings.CountedRounds = nil
+		}
+	}
+	if update.ClearRegistrationWindow {
+		settings.RegistrationOpensAt = nil
+		settings.RegistrationClosesAt = nil
+	}
+	if update.RegistrationOpensAt != nil {
+		settings.RegistrationOpensAt = update.RegistrationOpensAt
+	}
+	if update.RegistrationClosesAt != nil {
+		settings.RegistrationClosesAt = update.RegistrationClosesAt
+	}
+	if update.Venue != nil {
+		settings.Venue = *update.Venue
+	}
+	if update.Timezone != nil {
+		settings.Timezone = *update.Timezone
+	}
+	if update.Visibility != nil {
+		settings.Visibility = *update.Visibility
+	}
+	if update.MaxAttempts != nil {
+		settings.MaxAttempts = update.MaxAttempts
+		if *update.MaxAttempts == 0 {
+			settings.MaxAttempts = nil
+		}
+	}
+}
+
+// ValidateSettings checks the rules between settings that binding tags can't
+// express.
+func ValidateSettings(settings types.CompetitionSettings) error {
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", settings.Timezone)
+	}
+	if settings.RegistrationOpensAt != nil && settings.RegistrationClosesAt != nil &&
+		!settings.RegistrationClosesAt.After(*settings.RegistrationOpensAt) {
+		return errors.New("registration must close after it opens")
+	}
+	return nil
+}
+
+// GetRegistrationWindows returns the registration windows of an
+// organisation's competitions.
+func GetRegistrationWindows(ctx context.Context, organisation int) ([]types.RegistrationWindow, error) {
+	query := "SELECT registration_opens_at, registration_closes_at, timezone FROM competitions WHERE organisation_id = $1"
+	rows, err := config.DB.QueryContext(ctx, query, organisation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []types.RegistrationWindow
+	for rows.Next() {
+		var window types.RegistrationWindow
+		if err := rows.Scan(&window.OpensAt, &window.ClosesAt, &window.Timezone); err != nil {
+			return nil, fmt.Errorf("failed to scan registration window: %w", err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+// RegistrationOpen reports whether any of windows is open at now. With no
+// windows at all, as for an organisation without competitions, nothing limits
+// registration and it is open. Otherwise, when none is open, next is when the
+// next one opens in its competition's timezone, or nil if none will.
+func RegistrationOpen(windows []types.RegistrationWindow, now time.Time) (open bool, next *time.Time) {
+	if len(windows) == 0 {
+		return true, nil
+	}
+	for _, window := range windows {
+		opened := window.OpensAt == nil || !now.Before(*window.OpensAt)
+		closed := window.ClosesAt != nil && !now.Before(*window.ClosesAt)
+		if opened && !closed {
+			return true, nil
+		}
+		if !opened && (next == nil || window.OpensAt.Before(*next)) {
+			opens := *window.OpensAt
+			if location, err := time.LoadLocation(window.Timezone); err == nil {
+				opens = opens.In(location)
+			}
+			next = &opens
+		}
+	}
+	return false, next
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/josenymad/boulder-api/config"
+)
+
+// GenerateToken returns a random token and the hash to store for it.
+func GenerateToken() (token string, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	token = hex.EncodeToString(bytes)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes a token for storage and lookup. Tokens are random, so a
+// fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCompetitorToken issues a single-use token for a competitor that
+// expires after lifetime.
+func CreateCompetitorToken(ctx context.Context, competitor int, purpose string, lifetime time.Duration) (string, error) {
+	token, tokenHash, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	query := "INSERT INTO competitor_tokens (competitor_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)"
+	_, err = config.DB.ExecContext(ctx, query, competitor, purpose, tokenHash, time.Now().Add(lifetime))
+	if err != nil {
+		return "", fmt.Errorf("failed to create competitor token: %v", err)
+	}
+	return token, nil
+}
+
+// QueryRower runs a query that returns at most one row. Both *sql.DB and
+// *sql.Tx are QueryRowers.
+type QueryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ConsumeCompetitorToken marks an unused, unexpired token as used and returns
+// its competitor. The error is sql.ErrNoRows when there is no such token.
+func ConsumeCompetitorToken(ctx context.Context, db QueryRower, token string, purpose string) (competitor int, err error) {
+	query := `UPDATE competitor_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING competitor_id`
+	err = db.QueryRowContext(ctx, query, HashToken(token), purpose).Scan(&competitor)
+	return competitor, err
+}
@@ -1,20 +1,45 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/josenymad/boulder-api/config"
+	"github.com/josenymad/boulder-api/tracing"
+	"github.com/josenymad/boulder-api/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func GetNumberOfRounds(competition string) (count int, err error) {
-	query := "SELECT COUNT(*) FROM rounds WHERE competition_id = $1"
-	err = config.DB.QueryRow(query, competition).Scan(&count)
+// GetRoundNumbers returns the numbers of a competition's rounds in order,
+// only counting the given stage's rounds unless stage is empty.
+func GetRoundNumbers(ctx context.Context, competition string, stage string) ([]int, error) {
+	query := "SELECT DISTINCT round_number FROM rounds WHERE competition_id = $1 AND ($2 = '' OR stage = $2) ORDER BY round_number"
+	rows, err := config.DB.QueryContext(ctx, query, competition, stage)
 	if err != nil {
-		return 0, errors.New("failed to get round count")
+		return nil, errors.New("failed to get round numbers")
 	}
-	return count, nil
+	defer rows.Close()
+
+	var numbers []int
+	for rows.Next() {
+		var number int
+		if err := rows.Scan(&number); err != nil {
+			return nil, errors.New("failed to scan round numbers")
+		}
+		numbers = append(numbers, number)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to iterate over round numbers")
+	}
+	return numbers, nil
 }
 
 func RemoveLastComma(query string) string {
@@ -27,51 +52,441 @@ func RemoveLastComma(query string) string {
 	return query
 }
 
-func BuildScoresQueryString(category string, competition string) (query string, err error) {
-	queryStart := "SELECT competitor_name, SUM(points) AS TOTAL,\n"
-	queryEnd := fmt.Sprintf(
-		`FROM (
-		SELECT 
-			c.name AS competitor_name,
-			c.category_id, 
-			r.round_number,
-			s.points
-		FROM 
-			competitors c
-		INNER JOIN 
-			competition_categories cat ON c.category_id = cat.category_id
-		LEFT JOIN 
-			scores s ON c.competitor_id = s.competitor_id
-		LEFT JOIN 
-			boulder_problems bp ON s.problem_id = bp.problem_id
-		LEFT JOIN 
-			rounds r ON bp.round_id = r.round_id
-		WHERE
-			r.competition_id = %s
-	) AS subquery
-	WHERE
-		subquery.category_id = %s
-	GROUP BY
-		competitor_name
-	ORDER BY
-		TOTAL DESC;`,
-		competition,
-		category,
-	)
-
-	numberOfRounds, err := GetNumberOfRounds(competition)
+// BuildScoresQueryString builds the leaderboard query for a competition and
+// category, returning it with its arguments. Every competitor registered in the
+// category is listed, with zero points and a round_N_dns ("did not start") flag
+// for rounds they have no scores in, unless HideNonStarters is set, in which
+// case competitors with no scores are left out. When a Stage is set only that
+// stage's rounds are scored and given columns; after qualification only competitors on the
+// stage's start list are listed, and their carried points are added to the
+// total. Under dynamic scoring each top is worth the competition's points pool
+// divided by the number of competitors in the category who topped the problem,
+// except where an organiser has overridden the points.
+// Rows level on total are ordered by the competition's tie-break rule.
+func BuildScoresQueryString(ctx context.Context, options types.LeaderboardOptions, settings types.CompetitionSettings) (query string, args []interface{}, err error) {
+	roundNumbers, err := GetRoundNumbers(ctx, options.Competition, options.Stage)
 	if err != nil {
-		return query, errors.New("failed to get number of rounds")
+		return query, nil, err
+	}
+
+	args = []interface{}{options.Competition, options.Category}
+
+	queryStart := "SELECT c.competitor_id, c.name AS competitor_name, COALESCE(SUM(s.points), 0) AS total,\n"
+	if options.Stage != "" {
+		queryStart = "SELECT c.competitor_id, c.name AS competitor_name, COALESCE(SUM(s.points), 0) + COALESCE(MAX(sle.carried_points), 0) AS total,\n"
+		queryStart += "COALESCE(MAX(sle.carried_points), 0) AS carried_points,\n"
 	}
+	queryStart += "COUNT(CASE WHEN s.topped THEN s.score_id END) AS tops,\n"
+	queryStart += "COALESCE(SUM(CASE WHEN s.topped THEN s.attempts END), 0) AS top_attempts,\n"
 
 	var iteratedQuery string
-	for index := 1; index <= numberOfRounds; index++ {
-		iteratedQuery += fmt.Sprintf("SUM(CASE WHEN round_number = %d THEN points ELSE 0 END) AS round_%d,\n", index, index)
+	for _, number := range roundNumbers {
+		iteratedQuery += fmt.Sprintf("COALESCE(SUM(CASE WHEN r.round_number = %d THEN s.points END), 0) AS round_%d,\n", number, number)
+		iteratedQuery += fmt.Sprintf("COUNT(CASE WHEN r.round_number = %d THEN s.score_id END) = 0 AS round_%d_dns,\n", number, number)
+	}
+
+	iteratedQuery = RemoveLastComma(queryStart + iteratedQuery)
+
+	roundsJoin := "INNER JOIN rounds r ON bp.round_id = r.round_id AND r.competition_id = $1"
+	if options.Stage != "" {
+		args = append(args, options.Stage)
+		roundsJoin += " AND r.stage = $3"
+	}
+
+	scoresSource := "scores"
+	if settings.ScoringMode == types.ScoringModeDynamic {
+		args = append(args, settings.PointsPool)
+		scoresSource = fmt.Sprintf(`(
+			SELECT
+				ds.score_id,
+				ds.competitor_id,
+				ds.problem_id,
+				ds.attempts,
+				ds.topped,
+				CASE
+					WHEN ds.points_overridden THEN ds.points
+					WHEN ds.topped THEN ROUND($%d::NUMERIC / t.toppers)::INTEGER
+					ELSE 0
+				END AS points
+			FROM
+				scores ds
+			INNER JOIN
+				competitors dc ON ds.competitor_id = dc.competitor_id AND dc.category_id = $2
+			LEFT JOIN (
+				SELECT ts.problem_id, COUNT(DISTINCT ts.competitor_id) AS toppers
+				FROM scores ts
+				INNER JOIN competitors tc ON ts.competitor_id = tc.competitor_id AND tc.category_id = $2
+				WHERE ts.topped
+				GROUP BY ts.problem_id
+			) t ON ds.problem_id = t.problem_id
+		)`, len(args))
+	}
+
+	queryEnd := `FROM
+		competitors c
+	LEFT JOIN (
+		` + scoresSource + ` s
+		INNER JOIN boulder_problems bp ON s.problem_id = bp.problem_id
+		` + roundsJoin + `
+	) ON c.competitor_id = s.competitor_id
+	`
+
+	if options.Stage != "" {
+		queryEnd += `LEFT JOIN
+		start_list_entries sle ON c.competitor_id = sle.competitor_id AND sle.competition_id = $1 AND sle.stage = $3
+	`
+	}
+
+	queryEnd += `WHERE
+		c.category_id = $2
+	`
+
+	if options.Stage != "" && options.Stage != types.StageQualification {
+		queryEnd += `AND sle.entry_id IS NOT NULL
+	`
+	}
+
+	queryEnd += `GROUP BY
+		c.competitor_id, c.name
+	`
+
+	if options.HideNonStarters {
+		queryEnd += "HAVING\n\t\tCOUNT(s.score_id) > 0\n\t"
+	}
+
+	queryEnd += `ORDER BY
+		total DESC, ` + tieBreakOrder(settings.TieBreak) + `competitor_name;`
+
+	query = iteratedQuery + queryEnd
+
+	return query, args, err
+}
+
+// GetLeaderboard runs the leaderboard query for the given options and applies
+// the competition's counted rounds setting, returning rows ordered by total.
+func GetLeaderboard(ctx context.Context, options types.LeaderboardOptions) (totalScores []types.TotalScore, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "GetLeaderboard", trace.WithAttributes(
+		attribute.String("competition", options.Competition),
+		attribute.String("category", options.Category),
+		attribute.String("stage", options.Stage),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	settings, err := GetCompetitionSettings(ctx, options.Competition)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("scoring_mode", settings.ScoringMode))
+
+	query, args, err := BuildScoresQueryString(ctx, options, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scores query string: %v", err)
+	}
+
+	rows, err := config.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scores: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total scores columns: %v", err)
+	}
+	values := make([]interface{}, len(columns))
+	for rows.Next() {
+		for i := range values {
+			values[i] = new(interface{})
+		}
+
+		err := rows.Scan(values...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan total scores row: %v", err)
+		}
+
+		totalScore := make(types.TotalScore)
+		for i, column := range columns {
+			val := *(values[i].(*interface{}))
+			totalScore[column] = val
+		}
+		totalScores = append(totalScores, totalScore)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over total scores rows: %v", err)
+	}
+
+	if settings.CountedRounds != nil {
+		roundStatuses, err := GetRoundStatuses(ctx, options.Competition, options.Stage)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, totalScore := range totalScores {
+			ApplyCountedRounds(totalScore, roundStatuses, *settings.CountedRounds)
+		}
+		SortTotalScores(totalScores, settings.TieBreak)
+	}
+
+	return totalScores, nil
+}
+
+// GetRoundStatuses returns whether each of a competition's rounds has started
+// and finished, limited to one stage unless stage is empty.
+func GetRoundStatuses(ctx context.Context, competition string, stage string) ([]types.RoundStatus, error) {
+	query := "SELECT round_number, start_date <= NOW(), end_date <= NOW() FROM rounds WHERE competition_id = $1 AND ($2 = '' OR stage = $2) ORDER BY round_number"
+	rows, err := config.DB.QueryContext(ctx, query, competition, stage)
+	if err != nil {
+		return nil, errors.New("failed to get round statuses")
+	}
+	defer rows.Close()
+
+	var roundStatuses []types.RoundStatus
+	for rows.Next() {
+		var roundStatus types.RoundStatus
+		if err := rows.Scan(&roundStatus.Number, &roundStatus.Started, &roundStatus.Finished); err != nil {
+			return nil, errors.New("failed to scan round statuses")
+		}
+		roundStatuses = append(roundStatuses, roundStatus)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to iterate over round statuses")
+	}
+
+	return roundStatuses, nil
+}
+
+// ApplyCountedRounds recalculates a leaderboard row's total from its best
+// countedRounds rounds, plus any carried points, and lists the rest under
+// dropped_rounds. Rounds that have not started yet are ignored. Rounds in
+// progress compete with the points scored so far, and when two rounds are level
+// the finished one is kept, so a drop involving a round in progress can change
+// until that round ends.
+func ApplyCountedRounds(totalScore types.TotalScore, roundStatuses []types.RoundStatus, countedRounds int) {
+	var started []types.RoundStatus
+	for _, roundStatus := range roundStatuses {
+		if roundStatus.Started {
+			started = append(started, roundStatus)
+		}
+	}
+
+	roundPoints := func(roundStatus types.RoundStatus) int64 {
+		return ToInt64(totalScore[fmt.Sprintf("round_%d", roundStatus.Number)])
+	}
+
+	sort.SliceStable(started, func(i, j int) bool {
+		if roundPoints(started[i]) != roundPoints(started[j]) {
+			return roundPoints(started[i]) > roundPoints(started[j])
+		}
+		return started[i].Finished && !started[j].Finished
+	})
+
+	var total int64
+	droppedRounds := []int{}
+	for index, roundStatus := range started {
+		if index < countedRounds {
+			total += roundPoints(roundStatus)
+		} else {
+			droppedRounds = append(droppedRounds, roundStatus.Number)
+		}
+	}
+	sort.Ints(droppedRounds)
+
+	totalScore["total"] = total + ToInt64(totalScore["carried_points"])
+	totalScore["dropped_rounds"] = droppedRounds
+}
+
+// tieBreakOrder returns the ORDER BY terms that separate leaderboard rows level
+// on total, each followed by a comma.
+func tieBreakOrder(tieBreak string) string {
+	switch tieBreak {
+	case types.TieBreakMostTops:
+		return "tops DESC, "
+	case types.TieBreakFewestAttempts:
+		return "tops DESC, top_attempts ASC, "
+	default:
+		return ""
+	}
+}
+
+// SortTotalScores orders leaderboard rows by total, separating level rows with
+// the tie-break rule the same way the leaderboard query does.
+func SortTotalScores(totalScores []types.TotalScore, tieBreak string) {
+	sort.SliceStable(totalScores, func(i, j int) bool {
+		a, b := tieBreakKey(totalScores[i], tieBreak), tieBreakKey(totalScores[j], tieBreak)
+		for index := range a {
+			if a[index] != b[index] {
+				return a[index] > b[index]
+			}
+		}
+		return false
+	})
+}
+
+// tieBreakKey is what leaderboard rows are ranked on, highest first: the total,
+// then as much of tops and fewest top attempts as the tie-break rule uses.
+// Rows with equal keys are level.
+func tieBreakKey(totalScore types.TotalScore, tieBreak string) [3]int64 {
+	key := [3]int64{ToInt64(totalScore["total"])}
+	switch tieBreak {
+	case types.TieBreakMostTops:
+		key[1] = ToInt64(totalScore["tops"])
+	case types.TieBreakFewestAttempts:
+		key[1] = ToInt64(totalScore["tops"])
+		key[2] = -ToInt64(totalScore["top_attempts"])
+	}
+	return key
+}
+
+// ToInt64 converts a numeric value scanned from the database into an int64,
+// returning 0 for NULL or anything that is not a number.
+func ToInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case []byte:
+		number, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return 0
+		}
+		return int64(number)
+	default:
+		return 0
+	}
+}
+
+// NextStages returns the stages that follow stage, in order, or false if stage
+// is not a known stage.
+func NextStages(stage string) ([]string, bool) {
+	for index, candidate := range types.Stages {
+		if candidate == stage {
+			return types.Stages[index+1:], true
+		}
+	}
+	return nil, false
+}
+
+// SelectQualifiers picks the competitors who advance from a leaderboard ordered
+// by total and tieBreak. Competitors level with the last qualifying place on
+// total and the tie-break also advance, and level competitors share a position.
+func SelectQualifiers(totalScores []types.TotalScore, quota int, tieBreak string) []types.StartListEntry {
+	var qualifiers []types.StartListEntry
+	var previous [3]int64
+	for index, totalScore := range totalScores {
+		key := tieBreakKey(totalScore, tieBreak)
+		position := index + 1
+		if index > 0 && key == previous {
+			position = qualifiers[index-1].Position
+		}
+		if position > quota {
+			break
+		}
+		qualifiers = append(qualifiers, types.StartListEntry{
+			CompetitorID:  int(ToInt64(totalScore["competitor_id"])),
+			Position:      position,
+			CarriedPoints: ToInt64(totalScore["total"]),
+		})
+		previous = key
+	}
+	return qualifiers
+}
+
+// OrderStartList sorts entries into running order. Unranked competitors count
+// as ranked below everyone else, so they go last in rank order and first in
+// reverse rank order. A random draw depends only on the seed and the set of
+// competitors, so the same seed always gives the same order.
+func OrderStartList(entries []types.RunningOrderEntry, order string, seed int64) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CompetitorID < entries[j].CompetitorID
+	})
+
+	switch order {
+	case types.OrderRandom:
+		random := rand.New(rand.NewSource(seed))
+		random.Shuffle(len(entries), func(i, j int) {
+			entries[i], entries[j] = entries[j], entries[i]
+		})
+	case types.OrderRank, types.OrderReverseRank:
+		reverse := order == types.OrderReverseRank
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].Rank, entries[j].Rank
+			if a == nil || b == nil {
+				if reverse {
+					return a == nil && b != nil
+				}
+				return a != nil && b == nil
+			}
+			if reverse {
+				return *a > *b
+			}
+			return *a < *b
+		})
 	}
 
-	iteratedQuery = RemoveLastComma(iteratedQuery)
+	for index := range entries {
+		entries[index].Position = index + 1
+	}
+}
+
+// AssignTimeSlots gives each entry, in running order, a climbing slot of the
+// given length starting at start, and an isolation time that length of time
+// before its slot.
+func AssignTimeSlots(entries []types.RunningOrderEntry, start time.Time, slot time.Duration, isolation time.Duration) {
+	for index := range entries {
+		entries[index].SlotStart = start.Add(time.Duration(index) * slot)
+		entries[index].SlotEnd = entries[index].SlotStart.Add(slot)
+		entries[index].IsolationTime = entries[index].SlotStart.Add(-isolation)
+	}
+}
+
+// GetPointsConfig returns the points configuration for a boulder problem,
+// falling back to its competition's defaults for anything the problem doesn't
+// set.
+func GetPointsConfig(ctx context.Context, problem int) (pointsConfig types.PointsConfig, err error) {
+	query := `SELECT
+			COALESCE(bp.top_points, comp.top_points),
+			COALESCE(bp.zone_points, comp.zone_points),
+			COALESCE(bp.flash_bonus, comp.flash_bonus),
+			COALESCE(bp.attempt_penalty, comp.attempt_penalty),
+			bp.has_zone,
+			COALESCE(comp.max_attempts, 0)
+		FROM boulder_problems bp
+		INNER JOIN rounds r ON bp.round_id = r.round_id
+		INNER JOIN competitions comp ON r.competition_id = comp.competition_id
+		WHERE bp.problem_id = $1`
+	err = config.DB.QueryRowContext(ctx, query, problem).Scan(&pointsConfig.Top, &pointsConfig.Zone, &pointsConfig.FlashBonus,
+		&pointsConfig.AttemptPenalty, &pointsConfig.HasZone, &pointsConfig.MaxAttempts)
+	return pointsConfig, err
+}
+
+// CalculatePoints scores a result on a problem. A top is worth the top points,
+// plus the flash bonus on the first attempt, less the attempt penalty for each
+// attempt after the first, but never less than the zone would have scored.
+// Zones only score on problems that have one.
+func CalculatePoints(score types.Score, pointsConfig types.PointsConfig) int {
+	zonePoints := 0
+	if pointsConfig.HasZone && (score.Zone || score.Topped) {
+		zonePoints = pointsConfig.Zone
+	}
 
-	query = queryStart + iteratedQuery + queryEnd
+	if !score.Topped {
+		return zonePoints
+	}
+
+	points := pointsConfig.Top - pointsConfig.AttemptPenalty*(score.Attempts-1)
+	if score.Attempts == 1 {
+		points += pointsConfig.FlashBonus
+	}
 
-	return query, err
+	return max(points, zonePoints)
 }
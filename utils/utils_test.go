@@ -0,0 +1,334 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josenymad/boulder-api/types"
+	"github.com/josenymad/boulder-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCountedRounds(t *testing.T) {
+	roundStatuses := []types.RoundStatus{
+		{Number: 1, Started: true, Finished: true},
+		{Number: 2, Started: true, Finished: true},
+		{Number: 3, Started: true, Finished: true},
+		{Number: 4, Started: true, Finished: false},
+		{Number: 5, Started: false, Finished: false},
+	}
+	totalScore := types.TotalScore{
+		"competitor_name": "Test Competitor",
+		"total":           int64(60),
+		"round_1":         int64(20),
+		"round_2":         int64(5),
+		"round_3":         int64(15),
+		"round_4":         int64(20),
+		"round_5":         int64(0),
+	}
+
+	utils.ApplyCountedRounds(totalScore, roundStatuses, 2)
+
+	assert.Equal(t, int64(40), totalScore["total"])
+	assert.Equal(t, []int{2, 3}, totalScore["dropped_rounds"])
+}
+
+func TestApplyCountedRoundsPrefersFinishedRoundsOnTies(t *testing.T) {
+	roundStatuses := []types.RoundStatus{
+		{Number: 1, Started: true, Finished: false},
+		{Number: 2, Started: true, Finished: true},
+	}
+	totalScore := types.TotalScore{
+		"round_1": int64(10),
+		"round_2": int64(10),
+	}
+
+	utils.ApplyCountedRounds(totalScore, roundStatuses, 1)
+
+	assert.Equal(t, int64(10), totalScore["total"])
+	assert.Equal(t, []int{1}, totalScore["dropped_rounds"])
+}
+
+func TestSortTotalScores(t *testing.T) {
+	totalScores := []types.TotalScore{
+		{"competitor_name": "A", "total": int64(10)},
+		{"competitor_name": "B", "total": int64(30)},
+		{"competitor_name": "C", "total": int64(20)},
+	}
+
+	utils.SortTotalScores(totalScores, types.TieBreakNone)
+
+	assert.Equal(t, "B", totalScores[0]["competitor_name"])
+	assert.Equal(t, "C", totalScores[1]["competitor_name"])
+	assert.Equal(t, "A", totalScores[2]["competitor_name"])
+}
+
+func TestSortTotalScoresFewestAttemptsTieBreak(t *testing.T) {
+	totalScores := []types.TotalScore{
+		{"competitor_name": "A", "total": int64(20), "tops": int64(2), "top_attempts": int64(5)},
+		{"competitor_name": "B", "total": int64(20), "tops": int64(3), "top_attempts": int64(9)},
+		{"competitor_name": "C", "total": int64(20), "tops": int64(2), "top_attempts": int64(3)},
+	}
+
+	utils.SortTotalScores(totalScores, types.TieBreakFewestAttempts)
+
+	assert.Equal(t, "B", totalScores[0]["competitor_name"])
+	assert.Equal(t, "C", totalScores[1]["competitor_name"])
+	assert.Equal(t, "A", totalScores[2]["competitor_name"])
+}
+
+func TestSelectQualifiersIncludesTiesAtCutoff(t *testing.T) {
+	totalScores := []types.TotalScore{
+		{"competitor_id": int64(1), "total": int64(50)},
+		{"competitor_id": int64(2), "total": int64(40)},
+		{"competitor_id": int64(3), "total": int64(40)},
+		{"competitor_id": int64(4), "total": int64(30)},
+	}
+
+	qualifiers := utils.SelectQualifiers(totalScores, 2, types.TieBreakNone)
+
+	assert.Len(t, qualifiers, 3)
+	assert.Equal(t, 1, qualifiers[0].Position)
+	assert.Equal(t, 2, qualifiers[1].Position)
+	assert.Equal(t, 2, qualifiers[2].Position)
+	assert.Equal(t, 3, qualifiers[2].CompetitorID)
+	assert.Equal(t, int64(40), qualifiers[2].CarriedPoints)
+}
+
+func TestSelectQualifiersBreaksTiesAtCutoff(t *testing.T) {
+	totalScores := []types.TotalScore{
+		{"competitor_id": int64(1), "total": int64(50), "tops": int64(3)},
+		{"competitor_id": int64(2), "total": int64(40), "tops": int64(3)},
+		{"competitor_id": int64(3), "total": int64(40), "tops": int64(2)},
+		{"competitor_id": int64(4), "total": int64(40), "tops": int64(2)},
+	}
+
+	qualifiers := utils.SelectQualifiers(totalScores, 2, types.TieBreakMostTops)
+
+	assert.Len(t, qualifiers, 2)
+	assert.Equal(t, 2, qualifiers[1].CompetitorID)
+	assert.Equal(t, 2, qualifiers[1].Position)
+	assert.Equal(t, int64(40), qualifiers[1].CarriedPoints)
+
+	// Level on tops too, so the third and fourth share a position.
+	qualifiers = utils.SelectQualifiers(totalScores, 3, types.TieBreakMostTops)
+
+	assert.Len(t, qualifiers, 4)
+	assert.Equal(t, 3, qualifiers[2].Position)
+	assert.Equal(t, 3, qualifiers[3].Position)
+}
+
+func TestNextStages(t *testing.T) {
+	stages, ok := utils.NextStages(types.StageQualification)
+	assert.True(t, ok)
+	assert.Equal(t, []string{types.StageSemiFinal, types.StageFinal}, stages)
+
+	_, ok = utils.NextStages("warm_up")
+	assert.False(t, ok)
+}
+
+func TestOrderStartListRank(t *testing.T) {
+	first, second := 1, 2
+	entries := []types.RunningOrderEntry{
+		{CompetitorID: 1},
+		{CompetitorID: 2, Rank: &second},
+		{CompetitorID: 3, Rank: &first},
+	}
+
+	utils.OrderStartList(entries, types.OrderRank, 0)
+
+	assert.Equal(t, 3, entries[0].CompetitorID)
+	assert.Equal(t, 2, entries[1].CompetitorID)
+	assert.Equal(t, 1, entries[2].CompetitorID, "unranked competitors go last")
+	assert.Equal(t, 3, entries[2].Position)
+}
+
+func TestOrderStartListReverseRank(t *testing.T) {
+	first, second := 1, 2
+	entries := []types.RunningOrderEntry{
+		{CompetitorID: 1, Rank: &first},
+		{CompetitorID: 2},
+		{CompetitorID: 3, Rank: &second},
+	}
+
+	utils.OrderStartList(entries, types.OrderReverseRank, 0)
+
+	assert.Equal(t, 2, entries[0].CompetitorID)
+	assert.Equal(t, 3, entries[1].CompetitorID)
+	assert.Equal(t, 1, entries[2].CompetitorID)
+	assert.Equal(t, 3, entries[2].Position)
+}
+
+func TestOrderStartListRandomIsReproducible(t *testing.T) {
+	draw := func(competitorIDs ...int) []int {
+		var entries []types.RunningOrderEntry
+		for _, competitorID := range competitorIDs {
+			entries = append(entries, types.RunningOrderEntry{CompetitorID: competitorID})
+		}
+		utils.OrderStartList(entries, types.OrderRandom, 42)
+
+		var order []int
+		for _, entry := range entries {
+			order = append(order, entry.CompetitorID)
+		}
+		return order
+	}
+
+	assert.Equal(t, draw(1, 2, 3, 4, 5, 6), draw(6, 5, 4, 3, 2, 1))
+}
+
+func TestAssignTimeSlots(t *testing.T) {
+	entries := make([]types.RunningOrderEntry, 2)
+	start := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+
+	utils.AssignTimeSlots(entries, start, 5*time.Minute, 30*time.Minute)
+
+	assert.Equal(t, start, entries[0].SlotStart)
+	assert.Equal(t, start.Add(5*time.Minute), entries[0].SlotEnd)
+	assert.Equal(t, start.Add(5*time.Minute), entries[1].SlotStart)
+	assert.Equal(t, start.Add(-25*time.Minute), entries[1].IsolationTime)
+}
+
+func TestCalculatePoints(t *testing.T) {
+	pointsConfig := types.PointsConfig{
+		Top:            100,
+		Zone:           50,
+		FlashBonus:     10,
+		AttemptPenalty: 10,
+		HasZone:        true,
+	}
+
+	tests := []struct {
+		name   string
+		score  types.Score
+		points int
+	}{
+		{"flash", types.Score{Attempts: 1, Topped: true}, 110},
+		{"top in three", types.Score{Attempts: 3, Topped: true}, 80},
+		{"top never below zone", types.Score{Attempts: 12, Topped: true}, 50},
+		{"zone only", types.Score{Attempts: 4, Zone: true}, 50},
+		{"no zone or top", types.Score{Attempts: 2}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.points, utils.CalculatePoints(test.score, pointsConfig))
+		})
+	}
+}
+
+func TestCalculatePointsIgnoresZoneWithoutZoneHold(t *testing.T) {
+	pointsConfig := types.PointsConfig{Top: 100, Zone: 50, AttemptPenalty: 10}
+
+	assert.Equal(t, 0, utils.CalculatePoints(types.Score{Attempts: 1, Zone: true}, pointsConfig))
+	assert.Equal(t, 0, utils.CalculatePoints(types.Score{Attempts: 20, Topped: true}, pointsConfig))
+}
+
+func TestApplySettingsUpdate(t *testing.T) {
+	countedRounds := 6
+	settings := types.CompetitionSettings{
+		ScoringMode:   types.ScoringModeFixed,
+		Timezone:      "UTC",
+		CountedRounds: &countedRounds,
+	}
+	dynamic := types.ScoringModeDynamic
+	noLimit := 0
+
+	utils.ApplySettingsUpdate(&settings, types.CompetitionSettingsUpdate{
+		ScoringMode:   &dynamic,
+		CountedRounds: &noLimit,
+	})
+
+	assert.Equal(t, types.ScoringModeDynamic, settings.ScoringMode)
+	assert.Nil(t, settings.CountedRounds)
+	assert.Equal(t, "UTC", settings.Timezone)
+}
+
+func TestApplySettingsUpdateClearsRegistrationWindow(t *testing.T) {
+	opens := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+	closes := opens.Add(24 * time.Hour)
+	settings := types.CompetitionSettings{RegistrationOpensAt: &opens, RegistrationClosesAt: &closes}
+
+	utils.ApplySettingsUpdate(&settings, types.CompetitionSettingsUpdate{})
+	assert.Equal(t, &opens, settings.RegistrationOpensAt, "nil fields are left as they are")
+
+	utils.ApplySettingsUpdate(&settings, types.CompetitionSettingsUpdate{ClearRegistrationWindow: true, RegistrationClosesAt: &closes})
+	assert.Nil(t, settings.RegistrationOpensAt)
+	assert.Equal(t, &closes, settings.RegistrationClosesAt)
+
+	utils.ApplySettingsUpdate(&settings, types.CompetitionSettingsUpdate{ClearRegistrationWindow: true})
+	assert.Nil(t, settings.RegistrationOpensAt)
+	assert.Nil(t, settings.RegistrationClosesAt)
+}
+
+func TestValidateSettings(t *testing.T) {
+	opens := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+	closes := opens.Add(-time.Hour)
+
+	assert.NoError(t, utils.ValidateSettings(types.CompetitionSettings{Timezone: "Europe/London"}))
+	assert.Error(t, utils.ValidateSettings(types.CompetitionSettings{Timezone: "Mars/Olympus_Mons"}))
+	assert.Error(t, utils.ValidateSettings(types.CompetitionSettings{
+		Timezone:             "UTC",
+		RegistrationOpensAt:  &opens,
+		RegistrationClosesAt: &closes,
+	}))
+}
+
+func TestRegistrationOpen(t *testing.T) {
+	now := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	soon := now.Add(time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	open, next := utils.RegistrationOpen([]types.RegistrationWindow{{Timezone: "UTC"}}, now)
+	assert.True(t, open, "a competition without a window is always open")
+	assert.Nil(t, next)
+
+	open, next = utils.RegistrationOpen([]types.RegistrationWindow{
+		{ClosesAt: &past, Timezone: "UTC"},
+		{OpensAt: &later, Timezone: "UTC"},
+		{OpensAt: &soon, Timezone: "America/New_York"},
+	}, now)
+	assert.False(t, open)
+	if assert.NotNil(t, next) {
+		assert.True(t, soon.Equal(*next))
+		assert.Equal(t, "America/New_York", next.Location().String())
+	}
+
+	open, _ = utils.RegistrationOpen([]types.RegistrationWindow{{OpensAt: &past, ClosesAt: &soon, Timezone: "UTC"}}, now)
+	assert.True(t, open)
+
+	open, next = utils.RegistrationOpen(nil, now)
+	assert.True(t, open, "an organisation without competitions has no window to enforce")
+	assert.Nil(t, next)
+}
+
+func TestNormaliseEmail(t *testing.T) {
+	email, err := utils.NormaliseEmail("  Test.Climber@Mail.COM ")
+	assert.NoError(t, err)
+	assert.Equal(t, "test.climber@mail.com", email)
+
+	for _, invalid := range []string{"", "not-an-email", "climber@localhost", "Climber <climber@mail.com>", "a@b.com, c@d.com"} {
+		_, err := utils.NormaliseEmail(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := utils.NewRateLimiter(types.RateLimit{PerMinute: 6, Burst: 2})
+	now := time.Date(2024, time.July, 1, 10, 0, 0, 0, time.UTC)
+
+	allowed, _ := limiter.Allow("ip:192.0.2.1", now)
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("ip:192.0.2.1", now)
+	assert.True(t, allowed)
+	allowed, retryAfter := limiter.Allow("ip:192.0.2.1", now)
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	allowed, _ = limiter.Allow("ip:192.0.2.2", now)
+	assert.True(t, allowed, "other keys have their own bucket")
+
+	allowed, _ = limiter.Allow("ip:192.0.2.1", now.Add(10*time.Second))
+	assert.True(t, allowed, "a token is refilled every 10 seconds")
+}